@@ -2,8 +2,10 @@
 package cmd
 
 import (
+	"strconv"
 	"time"
 
+	"git.sr.ht/~hjertnes/timesheet/models"
 	"git.sr.ht/~hjertnes/timesheet/runner"
 	"git.sr.ht/~hjertnes/timesheet/utils"
 
@@ -14,14 +16,36 @@ import (
 type RunFunc struct {
 	r           runner.Runner
 	ExcludedOpt bool
+	EventOpt    models.EventItem
+	OvertimeOpt models.OvertimeRule
+	RateOpt     models.Rate
+	InvoiceOpt  runner.InvoiceOptions
+	BillableOpt bool
+	ProjectOpt  models.Project
+	ClientOpt   models.Client
+	ReportOpt   runner.ReportOptions
+	CSVOpt      runner.CSVOptions
+	ICSOpt      runner.ICSOptions
+	DryRunOpt   bool
+	FromOpt     string
+	ToOpt       string
+	FixOpt      bool
+	DescribeOpt bool
+	SetupOpt    runner.SetupOptions
 }
 
 func (r *RunFunc) settingsList(cmd *cobra.Command, args []string) {
-	r.r.SettingsList()
+	r.r.SettingsList(r.DescribeOpt)
 }
 func (r *RunFunc) settingsSet(cmd *cobra.Command, args []string) {
 	r.r.SettingsSet(args[0], args[1])
 }
+func (r *RunFunc) settingsUnset(cmd *cobra.Command, args []string) {
+	r.r.SettingsUnset(args[0])
+}
+func (r *RunFunc) settingsReset(cmd *cobra.Command, args []string) {
+	r.r.SettingsReset()
+}
 func (r *RunFunc) list(cmd *cobra.Command, args []string) {
 	r.r.List()
 }
@@ -31,13 +55,160 @@ func (r *RunFunc) off(cmd *cobra.Command, args []string) {
 	r.r.Off(date)
 }
 func (r *RunFunc) summaryDay(cmd *cobra.Command, args []string) {
-	r.r.SummaryDay()
+	r.r.SummaryDay(r.ReportOpt)
 }
 func (r *RunFunc) summaryYear(cmd *cobra.Command, args []string) {
 	r.r.SummaryYear()
 }
+func (r *RunFunc) summaryOvertime(cmd *cobra.Command, args []string) {
+	r.r.SummaryOvertime()
+}
+func (r *RunFunc) overtimeAdd(cmd *cobra.Command, args []string) {
+	multiplier, err := strconv.ParseFloat(args[1], 64)
+	utils.ErrorHandler(err)
+
+	var rule = r.OvertimeOpt
+	rule.Name = args[0]
+	rule.Multiplier = multiplier
+
+	r.r.OvertimeAdd(rule)
+}
+func (r *RunFunc) overtimeList(cmd *cobra.Command, args []string) {
+	r.r.OvertimeList()
+}
+func (r *RunFunc) overtimeRemove(cmd *cobra.Command, args []string) {
+	r.r.OvertimeRemove(args[0])
+}
+func (r *RunFunc) summaryEarnings(cmd *cobra.Command, args []string) {
+	r.r.SummaryEarnings()
+}
+func (r *RunFunc) rateAdd(cmd *cobra.Command, args []string) {
+	amount, err := strconv.ParseFloat(args[0], 64)
+	utils.ErrorHandler(err)
+
+	var rate = r.RateOpt
+	rate.Amount = amount
+	rate.Currency = args[1]
+
+	r.r.RateAdd(rate)
+}
+func (r *RunFunc) rateList(cmd *cobra.Command, args []string) {
+	r.r.RateList()
+}
+func (r *RunFunc) rateRemove(cmd *cobra.Command, args []string) {
+	number, err := utils.IntFromString(args[0])
+	utils.ErrorHandler(err)
+	r.r.RateRemove(number)
+}
+func (r *RunFunc) invoice(cmd *cobra.Command, args []string) {
+	r.r.Invoice(r.InvoiceOpt)
+}
+func (r *RunFunc) projectAdd(cmd *cobra.Command, args []string) {
+	var project = r.ProjectOpt
+	project.Name = args[0]
+
+	r.r.ProjectAdd(project)
+}
+func (r *RunFunc) projectList(cmd *cobra.Command, args []string) {
+	r.r.ProjectList()
+}
+func (r *RunFunc) projectEdit(cmd *cobra.Command, args []string) {
+	r.r.ProjectEdit(args[0], args[1], args[2])
+}
+func (r *RunFunc) projectStatus(cmd *cobra.Command, args []string) {
+	var name = ""
+	if len(args) == 1 {
+		name = args[0]
+	}
+
+	r.r.ProjectStatus(name)
+}
+func (r *RunFunc) projectRemove(cmd *cobra.Command, args []string) {
+	r.r.ProjectRemove(args[0])
+}
+func (r *RunFunc) clientAdd(cmd *cobra.Command, args []string) {
+	var client = r.ClientOpt
+	client.Name = args[0]
+
+	r.r.ClientAdd(client)
+}
+func (r *RunFunc) clientList(cmd *cobra.Command, args []string) {
+	r.r.ClientList()
+}
+func (r *RunFunc) clientEdit(cmd *cobra.Command, args []string) {
+	r.r.ClientEdit(args[0], args[1], args[2])
+}
+func (r *RunFunc) clientRemove(cmd *cobra.Command, args []string) {
+	r.r.ClientRemove(args[0])
+}
+func (r *RunFunc) importCSV(cmd *cobra.Command, args []string) {
+	r.r.ImportCSV(args[0], r.CSVOpt)
+}
+func (r *RunFunc) importICS(cmd *cobra.Command, args []string) {
+	r.r.ImportICS(r.ICSOpt)
+}
+func (r *RunFunc) exportICS(cmd *cobra.Command, args []string) {
+	var filename = ""
+	if len(args) == 1 {
+		filename = args[0]
+	}
+
+	r.r.ExportICS(filename)
+}
+func (r *RunFunc) importTimeclock(cmd *cobra.Command, args []string) {
+	r.r.ImportTimeclock(args[0], r.DryRunOpt)
+}
+func (r *RunFunc) exportTimeclock(cmd *cobra.Command, args []string) {
+	var filename = ""
+	if len(args) == 1 {
+		filename = args[0]
+	}
+
+	r.r.ExportTimeclock(filename)
+}
+func (r *RunFunc) exportXLSX(cmd *cobra.Command, args []string) {
+	r.r.ExportXLSX(args[0])
+}
+func (r *RunFunc) migrate(cmd *cobra.Command, args []string) {
+	r.r.Migrate(r.FromOpt, r.ToOpt)
+}
+func (r *RunFunc) doctor(cmd *cobra.Command, args []string) {
+	r.r.Doctor(r.FixOpt)
+}
+func (r *RunFunc) undo(cmd *cobra.Command, args []string) {
+	r.r.Undo(count(args))
+}
+func (r *RunFunc) redo(cmd *cobra.Command, args []string) {
+	r.r.Redo(count(args))
+}
+func (r *RunFunc) history(cmd *cobra.Command, args []string) {
+	var date = ""
+	if len(args) == 1 {
+		date = args[0]
+	}
+
+	r.r.History(date)
+}
+func (r *RunFunc) sync(cmd *cobra.Command, args []string) {
+	r.r.Sync()
+}
+func (r *RunFunc) merge(cmd *cobra.Command, args []string) {
+	r.r.Merge(args[0], args[1], args[2])
+}
 func (r *RunFunc) setup(cmd *cobra.Command, args []string) {
-	r.r.Setup()
+	var options = r.SetupOpt
+	options.Values = make(map[string]string)
+
+	for _, setting := range runner.Settings() {
+		if cmd.Flags().Changed(setting.Key) {
+			value, err := cmd.Flags().GetString(setting.Key)
+			utils.ErrorHandler(err)
+
+			options.Values[setting.Key] = value
+		}
+	}
+
+	r.r.Setup(options)
 }
 
 func (r *RunFunc) add(cmd *cobra.Command, args []string) {
@@ -53,7 +224,42 @@ func (r *RunFunc) add(cmd *cobra.Command, args []string) {
 	end, err = utils.TimeFromDateStringAndTimeString(args[0], args[2])
 	utils.ErrorHandler(err)
 
-	r.r.Add(start, end, r.ExcludedOpt)
+	var event = r.EventOpt
+	if cmd.Flags().Changed("billable") {
+		var billable = r.BillableOpt
+		event.Billable = &billable
+	}
+
+	r.r.Add(start, end, r.ExcludedOpt, event)
+}
+
+func (r *RunFunc) logBreak(cmd *cobra.Command, args []string) {
+	var date = time.Now().Format("2006-01-02")
+
+	if len(args) == 3 {
+		date = args[0]
+		args = args[1:]
+	}
+
+	start, err := utils.TimeFromDateStringAndTimeString(date, args[0])
+	utils.ErrorHandler(err)
+
+	end, err := utils.TimeFromDateStringAndTimeString(date, args[1])
+	utils.ErrorHandler(err)
+
+	r.r.Break(start, end)
+}
+
+// count reads the optional number of changes to undo or redo
+func count(args []string) int {
+	if len(args) == 0 {
+		return 1
+	}
+
+	number, err := utils.IntFromString(args[0])
+	utils.ErrorHandler(err)
+
+	return number
 }
 
 // New constructor
@@ -67,8 +273,10 @@ type builder struct {
 
 func (b *builder) root() *cobra.Command {
 	return &cobra.Command{
-		Use:  "timesheet",
-		Long: "A command line utility to keep track of worked hours",
+		Use: "timesheet",
+		Long: `A command line utility to keep track of worked hours. 
+The data is kept in ~/txt/timesheet.yaml, set TIMESHEET_STORAGE to yaml:<file> or sqlite:<file> to keep it elsewhere. 
+With encrypted:<file> the file is encrypted with a passphrase from TIMESHEET_KEY, the file in TIMESHEET_KEY_FILE or asked for`,
 	}
 }
 
@@ -84,7 +292,7 @@ func (b *builder) settingsList() *cobra.Command {
 	return &cobra.Command{
 		Use:   "list",
 		Short: "list settings",
-		Long:  "command to list current settings",
+		Long:  "command to list current settings, with the defaults of those that aren't set",
 		Args:  cobra.ExactArgs(0),
 		Run:   b.run.settingsList,
 	}
@@ -94,12 +302,32 @@ func (b *builder) settingsSet() *cobra.Command {
 	return &cobra.Command{
 		Use:   "set [key] [value]",
 		Short: "set or update settings",
-		Long:  "command to add or update settings",
+		Long:  "command to add or update settings, the value of the settings timesheet uses is checked",
 		Args:  cobra.ExactArgs(2),
 		Run:   b.run.settingsSet,
 	}
 }
 
+func (b *builder) settingsUnset() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset [key]",
+		Short: "unset a setting",
+		Long:  "command to remove a setting, so its default is used",
+		Args:  cobra.ExactArgs(1),
+		Run:   b.run.settingsUnset,
+	}
+}
+
+func (b *builder) settingsReset() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reset",
+		Short: "reset settings",
+		Long:  "command to remove every setting, so the defaults are used",
+		Args:  cobra.ExactArgs(0),
+		Run:   b.run.settingsReset,
+	}
+}
+
 func (b *builder) list() *cobra.Command {
 	return &cobra.Command{
 		Use:   "list",
@@ -131,14 +359,32 @@ func (b *builder) off() *cobra.Command {
 	}
 }
 
-func (b *builder) setup() *cobra.Command {
+func (b *builder) logBreak() *cobra.Command {
 	return &cobra.Command{
+		Use:   "break [date] [from] [to]",
+		Short: "add break",
+		Long: `logs a break on a given date, today if [date] is left out. 
+Days with logged breaks use them instead of the break setting. Formats: yyyy-mm-dd, hh:mm`,
+		Args: cobra.RangeArgs(2, 3),
+		Run:  b.run.logBreak,
+	}
+}
+
+func (b *builder) setup() *cobra.Command {
+	var cmd = &cobra.Command{
 		Use:   "setup",
 		Short: "set timesheet up",
-		Long:  "configure required settings. It will replace existing settings but not other data",
-		Args:  cobra.ExactArgs(0),
-		Run:   b.run.setup,
+		Long: `configure settings, asking for each with its current value as default. Settings given as flags aren't asked for, 
+and with --non-interactive only those are set. It will replace existing settings but not other data`,
+		Args: cobra.ExactArgs(0),
+		Run:  b.run.setup,
+	}
+
+	for _, setting := range runner.Settings() {
+		cmd.Flags().String(setting.Key, "", setting.Description)
 	}
+
+	return cmd
 }
 
 func (b *builder) summaryYear() *cobra.Command {
@@ -162,6 +408,388 @@ in a format that makes it easy to copy paste into our time tracking stuff at wor
 	}
 }
 
+func (b *builder) summaryOvertime() *cobra.Command {
+	return &cobra.Command{
+		Use:   "overtime",
+		Short: "show overtime per month",
+		Long: `shows worked hours per month split into normal time and 
+one column per overtime rule, plus the total weighted by the multipliers`,
+		Args: cobra.ExactArgs(0),
+		Run:  b.run.summaryOvertime,
+	}
+}
+
+func (b *builder) overtime() *cobra.Command {
+	return &cobra.Command{
+		Use:   "overtime [sub-command]",
+		Short: "overtime rules",
+		Long:  "manage overtime rules",
+	}
+}
+
+func (b *builder) overtimeAdd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add [name] [multiplier]",
+		Short: "add overtime rule",
+		Long: `adds a rule paying worked time with [multiplier] when all given conditions hold,
+e.g 1.5 after 540 minutes a day. When several rules match the highest multiplier wins`,
+		Args: cobra.ExactArgs(2),
+		Run:  b.run.overtimeAdd,
+	}
+}
+
+func (b *builder) overtimeList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list overtime rules",
+		Long:  "command to list overtime rules",
+		Args:  cobra.ExactArgs(0),
+		Run:   b.run.overtimeList,
+	}
+}
+
+func (b *builder) overtimeRemove() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm [name]",
+		Short: "remove overtime rule",
+		Long:  "command to remove an overtime rule",
+		Args:  cobra.ExactArgs(1),
+		Run:   b.run.overtimeRemove,
+	}
+}
+
+func (b *builder) summaryEarnings() *cobra.Command {
+	return &cobra.Command{
+		Use:   "earnings",
+		Short: "show earnings per month and client",
+		Long: `multiplies billable hours with the rate in effect for each project and day, 
+grouped by month and client. Hours are rounded by the rounding settings`,
+		Args: cobra.ExactArgs(0),
+		Run:  b.run.summaryEarnings,
+	}
+}
+
+func (b *builder) rate() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rate [sub-command]",
+		Short: "hourly rates",
+		Long:  "manage hourly rates",
+	}
+}
+
+func (b *builder) rateAdd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add [amount] [currency]",
+		Short: "add hourly rate",
+		Long: `adds an hourly rate for a project from a date on. 
+A rate with --client and without --project is used for the projects of that client,
+a rate without either for projects without a rate of their own`,
+		Args: cobra.ExactArgs(2),
+		Run:  b.run.rateAdd,
+	}
+}
+
+func (b *builder) rateList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list hourly rates",
+		Long:  "command to list hourly rates",
+		Args:  cobra.ExactArgs(0),
+		Run:   b.run.rateList,
+	}
+}
+
+func (b *builder) rateRemove() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm [number]",
+		Short: "remove hourly rate",
+		Long:  "command to remove the hourly rate with the number shown by rate list",
+		Args:  cobra.ExactArgs(1),
+		Run:   b.run.rateRemove,
+	}
+}
+
+func (b *builder) invoice() *cobra.Command {
+	return &cobra.Command{
+		Use:   "invoice",
+		Short: "generate invoice",
+		Long: `writes an invoice for the billable time of a client in a month with line items 
+per day or per task, using the rates, rounding and tax settings. 
+Every invoice gets the next invoice number, which is stored so it is never used again`,
+		Args: cobra.ExactArgs(0),
+		Run:  b.run.invoice,
+	}
+}
+
+func (b *builder) project() *cobra.Command {
+	return &cobra.Command{
+		Use:   "project [sub-command]",
+		Short: "projects",
+		Long:  "manage projects",
+	}
+}
+
+func (b *builder) projectAdd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add [name]",
+		Short: "add project",
+		Long:  "adds a project. Events on it are billable unless --billable=false is used",
+		Args:  cobra.ExactArgs(1),
+		Run:   b.run.projectAdd,
+	}
+}
+
+func (b *builder) projectList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list projects",
+		Long:  "command to list projects",
+		Args:  cobra.ExactArgs(0),
+		Run:   b.run.projectList,
+	}
+}
+
+func (b *builder) projectEdit() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit [name] [field] [value]",
+		Short: "edit project",
+		Long:  "command to update a field of a project. Fields: billable, client, budget",
+		Args:  cobra.ExactArgs(3),
+		Run:   b.run.projectEdit,
+	}
+}
+
+func (b *builder) projectStatus() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [name]",
+		Short: "show budget status",
+		Long: `shows hours used and remaining of the budget of a project, or of every project with a budget, 
+how many hours are used per week and when the budget runs out at that pace`,
+		Args: cobra.RangeArgs(0, 1),
+		Run:  b.run.projectStatus,
+	}
+}
+
+func (b *builder) projectRemove() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm [name]",
+		Short: "remove project",
+		Long:  "command to remove a project, events logged on it are kept",
+		Args:  cobra.ExactArgs(1),
+		Run:   b.run.projectRemove,
+	}
+}
+
+func (b *builder) client() *cobra.Command {
+	return &cobra.Command{
+		Use:   "client [sub-command]",
+		Short: "clients",
+		Long:  "manage clients",
+	}
+}
+
+func (b *builder) clientAdd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add [name]",
+		Short: "add client",
+		Long:  "adds a client that projects can be linked to",
+		Args:  cobra.ExactArgs(1),
+		Run:   b.run.clientAdd,
+	}
+}
+
+func (b *builder) clientList() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list clients",
+		Long:  "command to list clients",
+		Args:  cobra.ExactArgs(0),
+		Run:   b.run.clientList,
+	}
+}
+
+func (b *builder) clientEdit() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit [name] [field] [value]",
+		Short: "edit client",
+		Long: `command to update a field of a client. 
+Fields: address, vat, rate, currency, rounding, rounding_minutes, rounding_scope`,
+		Args: cobra.ExactArgs(3),
+		Run:  b.run.clientEdit,
+	}
+}
+
+func (b *builder) clientRemove() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm [name]",
+		Short: "remove client",
+		Long:  "command to remove a client no project is linked to",
+		Args:  cobra.ExactArgs(1),
+		Run:   b.run.clientRemove,
+	}
+}
+
+func (b *builder) importer() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import [sub-command]",
+		Short: "import events",
+		Long:  "import events from other tools",
+	}
+}
+
+func (b *builder) importCSV() *cobra.Command {
+	return &cobra.Command{
+		Use:   "csv [file]",
+		Short: "import csv",
+		Long: `imports events from a csv file, like the exports of Toggl (--preset toggl) or Clockify (--preset clockify). 
+Columns are mapped by their header, events that are already logged are skipped and nothing is added 
+if any row can't be read`,
+		Args: cobra.ExactArgs(1),
+		Run:  b.run.importCSV,
+	}
+}
+
+func (b *builder) importICS() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ics",
+		Short: "import calendar",
+		Long: `imports the meetings of an iCalendar file whose summary contains --match as events, 
+with the summary as note. All day and recurring events are skipped`,
+		Args: cobra.ExactArgs(0),
+		Run:  b.run.importICS,
+	}
+}
+
+func (b *builder) exporter() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export [sub-command]",
+		Short: "export events",
+		Long:  "export events for other tools",
+	}
+}
+
+func (b *builder) exportICS() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ics [file]",
+		Short: "export calendar",
+		Long:  "writes every event as an iCalendar event to [file], or stdout",
+		Args:  cobra.RangeArgs(0, 1),
+		Run:   b.run.exportICS,
+	}
+}
+
+func (b *builder) importTimeclock() *cobra.Command {
+	return &cobra.Command{
+		Use:   "timeclock [file]",
+		Short: "import timeclock",
+		Long: `imports the clock-ins and clock-outs of a ledger or hledger timeclock file as events, 
+with the account as project. Events that are already logged are skipped and nothing is added 
+if any entry can't be read`,
+		Args: cobra.ExactArgs(1),
+		Run:  b.run.importTimeclock,
+	}
+}
+
+func (b *builder) exportTimeclock() *cobra.Command {
+	return &cobra.Command{
+		Use:   "timeclock [file]",
+		Short: "export timeclock",
+		Long:  "writes every event as a clock-in and clock-out for ledger and hledger to [file], or stdout",
+		Args:  cobra.RangeArgs(0, 1),
+		Run:   b.run.exportTimeclock,
+	}
+}
+
+func (b *builder) exportXLSX() *cobra.Command {
+	return &cobra.Command{
+		Use:   "xlsx [file]",
+		Short: "export spreadsheet",
+		Long: `writes a workbook with a sheet per month, with the start, end, break and total of every day, 
+and a summary sheet per year. Totals are formulas, so they are recomputed when edited`,
+		Args: cobra.ExactArgs(1),
+		Run:  b.run.exportXLSX,
+	}
+}
+
+func (b *builder) migrate() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "copy data between storages",
+		Long: `copies settings and everything logged from one storage to another, like --from yaml:<file> --to sqlite:<file>, 
+and checks that the totals of every year match afterwards. The target has to be empty`,
+		Args: cobra.ExactArgs(0),
+		Run:  b.run.migrate,
+	}
+}
+
+func (b *builder) doctor() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "check the data",
+		Long: `checks that settings are valid and that every day has readable times, is filed under its year 
+and has no reversed or overlapping events, and shows the line of each problem. 
+With --fix times like 8:00 are written as 08:00:00, days are moved to their year and duplicate events are removed`,
+		Args: cobra.ExactArgs(0),
+		Run:  b.run.doctor,
+	}
+}
+
+func (b *builder) undo() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo [n]",
+		Short: "undo changes",
+		Long: `reverts the last change made by a command, or the last [n]. The last 100 changes are kept 
+next to the data as <file>.journal`,
+		Args: cobra.RangeArgs(0, 1),
+		Run:  b.run.undo,
+	}
+}
+
+func (b *builder) redo() *cobra.Command {
+	return &cobra.Command{
+		Use:   "redo [n]",
+		Short: "redo changes",
+		Long:  "makes the last undone change again, or the last [n]. Changes can't be redone after a new change is made",
+		Args:  cobra.RangeArgs(0, 1),
+		Run:   b.run.redo,
+	}
+}
+
+func (b *builder) history() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history [date]",
+		Short: "show changes",
+		Long: `shows when a day was logged or changed, by which command and what it was before and after, 
+or every change and the settings that changed when [date] is left out. Every change is added to <file>.audit next to the data`,
+		Args: cobra.RangeArgs(0, 1),
+		Run:  b.run.history,
+	}
+}
+
+func (b *builder) sync() *cobra.Command {
+	return &cobra.Command{
+		Use:   "sync",
+		Short: "sync with git",
+		Long: `commits the data, pulls and merges the changes of the git remote in the git_remote setting and pushes the result. 
+With the git setting on every change is committed as well`,
+		Args: cobra.ExactArgs(0),
+		Run:  b.run.sync,
+	}
+}
+
+func (b *builder) merge() *cobra.Command {
+	return &cobra.Command{
+		Use:   "merge [base] [ours] [theirs]",
+		Short: "merge timesheets",
+		Long: `merges the changes from [base] to [theirs] into [ours] and writes the result to [ours]. Days are merged event by event, 
+events added on both sides are kept unless they overlap. Changes on both sides that can't be combined are listed 
+as conflicts and [ours] is kept for them. To use it for git: git config merge.timesheet.driver "timesheet merge %O %A %B" 
+and add "timesheet.yaml merge=timesheet" to .gitattributes`,
+		Args: cobra.ExactArgs(3),
+		Run:  b.run.merge,
+	}
+}
+
 // Run builds and runs command
 func Run(run *RunFunc, runner runner.Runner) {
 	var b = &builder{
@@ -176,18 +804,98 @@ func Run(run *RunFunc, runner runner.Runner) {
 
 	var settingsSetCmd = b.settingsSet()
 
+	var settingsUnsetCmd = b.settingsUnset()
+
+	var settingsResetCmd = b.settingsReset()
+
 	var listCmd = b.list()
 
 	var offCmd = b.off()
 
 	var addCmd = b.add()
 
+	var breakCmd = b.logBreak()
+
 	var setupCmd = b.setup()
 
 	var summaryCmd = b.summaryYear()
 
 	var summaryDayCmd = b.summaryDay()
 
+	var summaryOvertimeCmd = b.summaryOvertime()
+
+	var overtimeCmd = b.overtime()
+
+	var overtimeAddCmd = b.overtimeAdd()
+
+	var overtimeListCmd = b.overtimeList()
+
+	var overtimeRemoveCmd = b.overtimeRemove()
+
+	var summaryEarningsCmd = b.summaryEarnings()
+
+	var rateCmd = b.rate()
+
+	var rateAddCmd = b.rateAdd()
+
+	var rateListCmd = b.rateList()
+
+	var rateRemoveCmd = b.rateRemove()
+
+	var invoiceCmd = b.invoice()
+
+	var projectCmd = b.project()
+
+	var projectAddCmd = b.projectAdd()
+
+	var projectListCmd = b.projectList()
+
+	var projectEditCmd = b.projectEdit()
+
+	var projectStatusCmd = b.projectStatus()
+
+	var projectRemoveCmd = b.projectRemove()
+
+	var clientCmd = b.client()
+
+	var clientAddCmd = b.clientAdd()
+
+	var clientListCmd = b.clientList()
+
+	var clientEditCmd = b.clientEdit()
+
+	var clientRemoveCmd = b.clientRemove()
+
+	var importCmd = b.importer()
+
+	var importCSVCmd = b.importCSV()
+
+	var importICSCmd = b.importICS()
+
+	var exportCmd = b.exporter()
+
+	var exportICSCmd = b.exportICS()
+
+	var importTimeclockCmd = b.importTimeclock()
+
+	var exportTimeclockCmd = b.exportTimeclock()
+
+	var exportXLSXCmd = b.exportXLSX()
+
+	var migrateCmd = b.migrate()
+
+	var doctorCmd = b.doctor()
+
+	var undoCmd = b.undo()
+
+	var redoCmd = b.redo()
+
+	var historyCmd = b.history()
+
+	var syncCmd = b.sync()
+
+	var mergeCmd = b.merge()
+
 	addCmd.Flags().BoolVarP(
 		&run.ExcludedOpt,
 		"excluded",
@@ -195,15 +903,275 @@ func Run(run *RunFunc, runner runner.Runner) {
 		false,
 		"will cause the days you use it on to not have break time deducted(e.g working extra hours during the weekend)",
 	)
+	addCmd.Flags().StringVarP(
+		&run.EventOpt.Project,
+		"project",
+		"p",
+		"",
+		"the project the time was spent on",
+	)
+	addCmd.Flags().StringVarP(
+		&run.EventOpt.Note,
+		"note",
+		"n",
+		"",
+		"what the time was spent on, used as task on invoices",
+	)
+	addCmd.Flags().BoolVarP(
+		&run.BillableOpt,
+		"billable",
+		"b",
+		true,
+		"if the time is billed to the client, defaults to the setting of the project",
+	)
+
+	overtimeAddCmd.Flags().IntVar(
+		&run.OvertimeOpt.DailyThreshold,
+		"daily",
+		0,
+		"minutes worked in a day before the rule applies",
+	)
+	overtimeAddCmd.Flags().IntVar(
+		&run.OvertimeOpt.WeeklyThreshold,
+		"weekly",
+		0,
+		"minutes worked in a week (monday to sunday) before the rule applies",
+	)
+	overtimeAddCmd.Flags().StringSliceVar(
+		&run.OvertimeOpt.Weekdays,
+		"weekday",
+		nil,
+		"weekdays the rule applies to, e.g sunday or sat,sun",
+	)
+	overtimeAddCmd.Flags().StringVar(
+		&run.OvertimeOpt.From,
+		"from",
+		"",
+		"start of the time of day window the rule applies to. Format: hh:mm",
+	)
+	overtimeAddCmd.Flags().StringVar(
+		&run.OvertimeOpt.To,
+		"to",
+		"",
+		"end of the time of day window the rule applies to. Format: hh:mm",
+	)
+	overtimeAddCmd.Flags().BoolVar(
+		&run.OvertimeOpt.Excluded,
+		"excluded",
+		false,
+		"only apply the rule to days logged with --excluded",
+	)
+
+	rateAddCmd.Flags().StringVarP(
+		&run.RateOpt.Project,
+		"project",
+		"p",
+		"",
+		"the project the rate is for",
+	)
+	rateAddCmd.Flags().StringVarP(
+		&run.RateOpt.Client,
+		"client",
+		"c",
+		"",
+		"the client billed at this rate",
+	)
+	rateAddCmd.Flags().StringVar(
+		&run.RateOpt.From,
+		"from",
+		"",
+		"the first day the rate is in effect. Format: yyyy-mm-dd",
+	)
+
+	invoiceCmd.Flags().StringVarP(
+		&run.InvoiceOpt.Client,
+		"client",
+		"c",
+		"",
+		"the client to invoice",
+	)
+	invoiceCmd.Flags().StringVarP(
+		&run.InvoiceOpt.Month,
+		"month",
+		"m",
+		time.Now().AddDate(0, -1, 0).Format("2006-01"),
+		"the month to invoice. Format: yyyy-mm",
+	)
+	invoiceCmd.Flags().StringVar(
+		&run.InvoiceOpt.Per,
+		"per",
+		"day",
+		"line items per day or per task",
+	)
+	invoiceCmd.Flags().StringVarP(
+		&run.InvoiceOpt.Format,
+		"format",
+		"f",
+		"markdown",
+		"markdown, html or text",
+	)
+	invoiceCmd.Flags().StringVarP(
+		&run.InvoiceOpt.Output,
+		"output",
+		"o",
+		"",
+		"file to write the invoice to instead of stdout",
+	)
+
+	projectAddCmd.Flags().BoolVarP(
+		&run.ProjectOpt.Billable,
+		"billable",
+		"b",
+		true,
+		"if time on the project is billed to the client",
+	)
+	projectAddCmd.Flags().StringVarP(
+		&run.ProjectOpt.Client,
+		"client",
+		"c",
+		"",
+		"the client the project is billed to",
+	)
+	projectAddCmd.Flags().Float64Var(
+		&run.ProjectOpt.Budget,
+		"budget",
+		0,
+		"hours budgeted for the project",
+	)
+	clientAddCmd.Flags().StringVar(
+		&run.ClientOpt.Address,
+		"address",
+		"",
+		"postal address, printed on invoices",
+	)
+	clientAddCmd.Flags().StringVar(
+		&run.ClientOpt.VAT,
+		"vat",
+		"",
+		"VAT id, printed on invoices",
+	)
+	clientAddCmd.Flags().Float64Var(
+		&run.ClientOpt.Rate,
+		"rate",
+		0,
+		"default hourly rate for projects of the client without a rate of their own",
+	)
+	clientAddCmd.Flags().StringVar(
+		&run.ClientOpt.Currency,
+		"currency",
+		"",
+		"currency of the default rate",
+	)
+	clientAddCmd.Flags().StringVar(
+		&run.ClientOpt.Rounding,
+		"rounding",
+		"",
+		"nearest, up, down or none, overrides the rounding setting for the client",
+	)
+	clientAddCmd.Flags().IntVar(
+		&run.ClientOpt.RoundingMinutes,
+		"rounding-minutes",
+		0,
+		"overrides the rounding_minutes setting for the client",
+	)
+	clientAddCmd.Flags().StringVar(
+		&run.ClientOpt.RoundingScope,
+		"rounding-scope",
+		"",
+		"event, day or project, overrides the rounding_scope setting for the client",
+	)
+	summaryDayCmd.Flags().StringVarP(
+		&run.ReportOpt.Client,
+		"client",
+		"c",
+		"",
+		"only show time billed to this client",
+	)
+	summaryDayCmd.Flags().BoolVar(
+		&run.ReportOpt.ByClient,
+		"by-client",
+		false,
+		"show the hours of each client on a line of its own",
+	)
+
+	importCSVCmd.Flags().StringVar(&run.CSVOpt.Preset, "preset", "", "column mapping of a known tool: toggl or clockify")
+	importCSVCmd.Flags().StringVar(&run.CSVOpt.Date, "date", "", "column with the date")
+	importCSVCmd.Flags().StringVar(&run.CSVOpt.Start, "start", "", "column with the start time")
+	importCSVCmd.Flags().StringVar(&run.CSVOpt.End, "end", "", "column with the end time")
+	importCSVCmd.Flags().StringVar(&run.CSVOpt.Duration, "duration", "", "column with the duration, used when there is no end")
+	importCSVCmd.Flags().StringVar(&run.CSVOpt.Project, "project", "", "column with the project")
+	importCSVCmd.Flags().StringVar(&run.CSVOpt.Note, "note", "", "column with the note")
+	importCSVCmd.Flags().StringVar(&run.CSVOpt.DateFormat, "date-format", "", "format of the dates as a go layout, e.g 01/02/2006")
+	importCSVCmd.Flags().StringVar(&run.CSVOpt.Delimiter, "delimiter", "", "field delimiter, defaults to ,")
+	importCSVCmd.Flags().BoolVar(&run.CSVOpt.DryRun, "dry-run", false, "only show what would be added")
+
+	importICSCmd.Flags().StringVar(&run.ICSOpt.Calendar, "calendar", "", "the iCalendar file to import")
+	importICSCmd.Flags().StringVar(&run.ICSOpt.Match, "match", "", "only import events with a summary containing this")
+	importICSCmd.Flags().StringVarP(&run.ICSOpt.Project, "project", "p", "", "the project of the imported events")
+	importICSCmd.Flags().BoolVar(&run.ICSOpt.DryRun, "dry-run", false, "only show what would be added")
+	_ = importICSCmd.MarkFlagRequired("calendar")
+
+	importTimeclockCmd.Flags().BoolVar(&run.DryRunOpt, "dry-run", false, "only show what would be added")
+
+	migrateCmd.Flags().StringVar(&run.FromOpt, "from", "", "the storage to copy from, like yaml:<file>")
+	migrateCmd.Flags().StringVar(&run.ToOpt, "to", "", "the empty storage to copy to, like sqlite:<file>")
+	_ = migrateCmd.MarkFlagRequired("from")
+	_ = migrateCmd.MarkFlagRequired("to")
+
+	setupCmd.Flags().BoolVar(&run.SetupOpt.NonInteractive, "non-interactive", false, "only set the settings given as flags")
+
+	settingsListCmd.Flags().BoolVar(&run.DescribeOpt, "describe", false, "show the default and a description of each setting")
+
+	doctorCmd.Flags().BoolVar(&run.FixOpt, "fix", false, "make the repairs that are safe")
 
 	settingsCmd.AddCommand(settingsListCmd)
 	settingsCmd.AddCommand(settingsSetCmd)
+	settingsCmd.AddCommand(settingsUnsetCmd)
+	settingsCmd.AddCommand(settingsResetCmd)
 	rootCmd.AddCommand(settingsCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(offCmd)
 	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(breakCmd)
 	rootCmd.AddCommand(setupCmd)
 	summaryCmd.AddCommand(summaryDayCmd)
+	summaryCmd.AddCommand(summaryOvertimeCmd)
+	overtimeCmd.AddCommand(overtimeAddCmd)
+	overtimeCmd.AddCommand(overtimeListCmd)
+	overtimeCmd.AddCommand(overtimeRemoveCmd)
+	rootCmd.AddCommand(overtimeCmd)
+	summaryCmd.AddCommand(summaryEarningsCmd)
+	rateCmd.AddCommand(rateAddCmd)
+	rateCmd.AddCommand(rateListCmd)
+	rateCmd.AddCommand(rateRemoveCmd)
+	rootCmd.AddCommand(rateCmd)
+	rootCmd.AddCommand(invoiceCmd)
+	projectCmd.AddCommand(projectAddCmd)
+	projectCmd.AddCommand(projectListCmd)
+	projectCmd.AddCommand(projectEditCmd)
+	projectCmd.AddCommand(projectStatusCmd)
+	projectCmd.AddCommand(projectRemoveCmd)
+	rootCmd.AddCommand(projectCmd)
+	clientCmd.AddCommand(clientAddCmd)
+	clientCmd.AddCommand(clientListCmd)
+	clientCmd.AddCommand(clientEditCmd)
+	clientCmd.AddCommand(clientRemoveCmd)
+	rootCmd.AddCommand(clientCmd)
+	importCmd.AddCommand(importCSVCmd)
+	importCmd.AddCommand(importICSCmd)
+	importCmd.AddCommand(importTimeclockCmd)
+	rootCmd.AddCommand(importCmd)
+	exportCmd.AddCommand(exportICSCmd)
+	exportCmd.AddCommand(exportTimeclockCmd)
+	exportCmd.AddCommand(exportXLSXCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(redoCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(mergeCmd)
 	rootCmd.AddCommand(summaryCmd)
 	_ = rootCmd.Execute()
 }
@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/runner"
 	"github.com/spf13/cobra"
 
 	"github.com/stretchr/testify/mock"
@@ -13,30 +15,135 @@ type RunnerMock struct {
 	mock.Mock
 }
 
-func (m *RunnerMock) SettingsList() {
-	m.Called()
+func (m *RunnerMock) SettingsList(describe bool) {
+	m.Called(describe)
 }
 func (m *RunnerMock) SettingsSet(key string, value string) {
 	m.Called(key, value)
 }
+func (m *RunnerMock) SettingsUnset(key string) {
+	m.Called(key)
+}
+func (m *RunnerMock) SettingsReset() {
+	m.Called()
+}
 func (m *RunnerMock) List() {
 	m.Called()
 }
-func (m *RunnerMock) Add(start time.Time, end time.Time, excluded bool) {
-	m.Called(start, end, excluded)
+func (m *RunnerMock) Add(start time.Time, end time.Time, excluded bool, event models.EventItem) {
+	m.Called(start, end, excluded, event)
 }
 func (m *RunnerMock) Off(date time.Time) {
 	m.Called(date)
 }
-func (m *RunnerMock) Setup() {
-	m.Called()
+func (m *RunnerMock) Break(start time.Time, end time.Time) {
+	m.Called(start, end)
+}
+func (m *RunnerMock) Setup(options runner.SetupOptions) {
+	m.Called(options)
 }
 func (m *RunnerMock) SummaryYear() {
 	m.Called()
 }
-func (m *RunnerMock) SummaryDay() {
+func (m *RunnerMock) SummaryDay(options runner.ReportOptions) {
+	m.Called(options)
+}
+func (m *RunnerMock) SummaryOvertime() {
+	m.Called()
+}
+func (m *RunnerMock) OvertimeAdd(rule models.OvertimeRule) {
+	m.Called(rule)
+}
+func (m *RunnerMock) OvertimeList() {
+	m.Called()
+}
+func (m *RunnerMock) OvertimeRemove(name string) {
+	m.Called(name)
+}
+func (m *RunnerMock) SummaryEarnings() {
+	m.Called()
+}
+func (m *RunnerMock) RateAdd(rate models.Rate) {
+	m.Called(rate)
+}
+func (m *RunnerMock) RateList() {
+	m.Called()
+}
+func (m *RunnerMock) RateRemove(number int) {
+	m.Called(number)
+}
+func (m *RunnerMock) Invoice(options runner.InvoiceOptions) {
+	m.Called(options)
+}
+func (m *RunnerMock) ProjectAdd(project models.Project) {
+	m.Called(project)
+}
+func (m *RunnerMock) ProjectList() {
+	m.Called()
+}
+func (m *RunnerMock) ProjectEdit(name string, field string, value string) {
+	m.Called(name, field, value)
+}
+func (m *RunnerMock) ProjectRemove(name string) {
+	m.Called(name)
+}
+func (m *RunnerMock) ProjectStatus(name string) {
+	m.Called(name)
+}
+func (m *RunnerMock) ImportCSV(filename string, options runner.CSVOptions) {
+	m.Called(filename, options)
+}
+func (m *RunnerMock) ImportICS(options runner.ICSOptions) {
+	m.Called(options)
+}
+func (m *RunnerMock) ExportICS(filename string) {
+	m.Called(filename)
+}
+func (m *RunnerMock) ImportTimeclock(filename string, dryRun bool) {
+	m.Called(filename, dryRun)
+}
+func (m *RunnerMock) ExportTimeclock(filename string) {
+	m.Called(filename)
+}
+func (m *RunnerMock) ExportXLSX(filename string) {
+	m.Called(filename)
+}
+func (m *RunnerMock) Migrate(from string, to string) {
+	m.Called(from, to)
+}
+func (m *RunnerMock) Doctor(fix bool) {
+	m.Called(fix)
+}
+func (m *RunnerMock) Undo(n int) {
+	m.Called(n)
+}
+func (m *RunnerMock) Redo(n int) {
+	m.Called(n)
+}
+func (m *RunnerMock) History(date string) {
+	m.Called(date)
+}
+func (m *RunnerMock) Commit(message string) {
+	m.Called(message)
+}
+func (m *RunnerMock) Sync() {
+	m.Called()
+}
+func (m *RunnerMock) Merge(base string, ours string, theirs string) {
+	m.Called(base, ours, theirs)
+}
+func (m *RunnerMock) ClientAdd(client models.Client) {
+	m.Called(client)
+}
+func (m *RunnerMock) ClientList() {
 	m.Called()
 }
+func (m *RunnerMock) ClientEdit(name string, field string, value string) {
+	m.Called(name, field, value)
+}
+func (m *RunnerMock) ClientRemove(name string) {
+	m.Called(name)
+}
 
 func TestRun(t *testing.T) {
 	var m = &RunnerMock{}
@@ -75,10 +182,29 @@ func TestRunFuncAdd(t *testing.T) {
 
 	var cmd = &cobra.Command{}
 
-	m.On("Add", mock.Anything, mock.Anything, mock.Anything).Return()
+	m.On("Add", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
 	r.add(cmd, []string{"2010-01-01", "08:00", "16:00"})
 }
 
+func TestRunFuncBreak(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	var start = time.Date(2010, 1, 1, 11, 30, 0, 0, time.UTC)
+
+	var end = time.Date(2010, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	m.On("Break", start, end).Return()
+	r.logBreak(cmd, []string{"2010-01-01", "11:30", "12:00"})
+	m.AssertExpectations(t)
+
+	m.On("Break", mock.Anything, mock.Anything).Return()
+	r.logBreak(cmd, []string{"11:30", "12:00"})
+}
+
 func TestRunFuncSettingsList(t *testing.T) {
 	var m = &RunnerMock{}
 
@@ -86,8 +212,12 @@ func TestRunFuncSettingsList(t *testing.T) {
 
 	var cmd = &cobra.Command{}
 
-	m.On("SettingsList").Return()
+	m.On("SettingsList", false).Return()
+	r.settingsList(cmd, []string{})
+	r.DescribeOpt = true
+	m.On("SettingsList", true).Return()
 	r.settingsList(cmd, []string{})
+	m.AssertExpectations(t)
 }
 
 func TestRunFuncSettingsSet(t *testing.T) {
@@ -101,6 +231,20 @@ func TestRunFuncSettingsSet(t *testing.T) {
 	r.settingsSet(cmd, []string{"A", "B"})
 }
 
+func TestRunFuncSettingsUnset(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("SettingsUnset", "break").Return()
+	r.settingsUnset(cmd, []string{"break"})
+	m.On("SettingsReset").Return()
+	r.settingsReset(cmd, []string{})
+	m.AssertExpectations(t)
+}
+
 func TestRunFuncSettingsSetup(t *testing.T) {
 	var m = &RunnerMock{}
 
@@ -108,8 +252,16 @@ func TestRunFuncSettingsSetup(t *testing.T) {
 
 	var cmd = &cobra.Command{}
 
-	m.On("Setup").Return()
+	m.On("Setup", runner.SetupOptions{Values: map[string]string{}}).Return()
+	r.setup(cmd, []string{})
+
+	cmd.Flags().String("workday", "", "")
+	_ = cmd.Flags().Set("workday", "480")
+	r.SetupOpt.NonInteractive = true
+
+	m.On("Setup", runner.SetupOptions{Values: map[string]string{"workday": "480"}, NonInteractive: true}).Return()
 	r.setup(cmd, []string{})
+	m.AssertExpectations(t)
 }
 
 func TestRunFuncSummaryYear(t *testing.T) {
@@ -130,6 +282,406 @@ func TestRunFuncSummaryDay(t *testing.T) {
 
 	var cmd = &cobra.Command{}
 
-	m.On("SummaryDay").Return()
+	r.ReportOpt.Client = "acme"
+
+	m.On("SummaryDay", runner.ReportOptions{Client: "acme"}).Return()
 	r.summaryDay(cmd, []string{})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncSummaryOvertime(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("SummaryOvertime").Return()
+	r.summaryOvertime(cmd, []string{})
+}
+
+func TestRunFuncOvertimeAdd(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	r.OvertimeOpt.DailyThreshold = 540
+
+	m.On("OvertimeAdd", models.OvertimeRule{Name: "A", Multiplier: 1.5, DailyThreshold: 540}).Return()
+	r.overtimeAdd(cmd, []string{"A", "1.5"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncOvertimeList(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("OvertimeList").Return()
+	r.overtimeList(cmd, []string{})
+}
+
+func TestRunFuncOvertimeRemove(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("OvertimeRemove", "A").Return()
+	r.overtimeRemove(cmd, []string{"A"})
+}
+
+func TestRunFuncSummaryEarnings(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("SummaryEarnings").Return()
+	r.summaryEarnings(cmd, []string{})
+}
+
+func TestRunFuncRateAdd(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	r.RateOpt.Project = "a"
+
+	m.On("RateAdd", models.Rate{Project: "a", Amount: 900, Currency: "NOK"}).Return()
+	r.rateAdd(cmd, []string{"900", "NOK"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncRateList(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("RateList").Return()
+	r.rateList(cmd, []string{})
+}
+
+func TestRunFuncRateRemove(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("RateRemove", 1).Return()
+	r.rateRemove(cmd, []string{"1"})
+}
+
+func TestRunFuncInvoice(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	r.InvoiceOpt = runner.InvoiceOptions{Client: "acme", Month: "2026-09", Per: "day", Format: "text"}
+
+	m.On("Invoice", r.InvoiceOpt).Return()
+	r.invoice(cmd, []string{})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncAddBillable(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	var billable = false
+
+	cmd.Flags().BoolVar(&r.BillableOpt, "billable", true, "")
+	_ = cmd.Flags().Set("billable", "false")
+
+	m.On("Add", mock.Anything, mock.Anything, false, models.EventItem{Billable: &billable}).Return()
+	r.add(cmd, []string{"2010-01-01", "08:00", "16:00"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncProjectAdd(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ProjectAdd", models.Project{Name: "a"}).Return()
+	r.projectAdd(cmd, []string{"a"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncProjectList(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ProjectList").Return()
+	r.projectList(cmd, []string{})
+}
+
+func TestRunFuncProjectEdit(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ProjectEdit", "a", "billable", "false").Return()
+	r.projectEdit(cmd, []string{"a", "billable", "false"})
+}
+
+func TestRunFuncProjectStatus(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ProjectStatus", "").Return()
+	r.projectStatus(cmd, []string{})
+	m.On("ProjectStatus", "a").Return()
+	r.projectStatus(cmd, []string{"a"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncProjectRemove(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ProjectRemove", "a").Return()
+	r.projectRemove(cmd, []string{"a"})
+}
+
+func TestRunFuncClientAdd(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	r.ClientOpt.VAT = "NO123"
+
+	m.On("ClientAdd", models.Client{Name: "acme", VAT: "NO123"}).Return()
+	r.clientAdd(cmd, []string{"acme"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncClientList(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ClientList").Return()
+	r.clientList(cmd, []string{})
+}
+
+func TestRunFuncClientEdit(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ClientEdit", "acme", "vat", "NO123").Return()
+	r.clientEdit(cmd, []string{"acme", "vat", "NO123"})
+}
+
+func TestRunFuncClientRemove(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ClientRemove", "acme").Return()
+	r.clientRemove(cmd, []string{"acme"})
+}
+
+func TestRunFuncImportCSV(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	r.CSVOpt.Preset = "toggl"
+
+	m.On("ImportCSV", "export.csv", runner.CSVOptions{Preset: "toggl"}).Return()
+	r.importCSV(cmd, []string{"export.csv"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncImportICS(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	r.ICSOpt = runner.ICSOptions{Calendar: "work.ics", Match: "Client X"}
+
+	m.On("ImportICS", r.ICSOpt).Return()
+	r.importICS(cmd, []string{})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncExportICS(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ExportICS", "").Return()
+	r.exportICS(cmd, []string{})
+	m.On("ExportICS", "work.ics").Return()
+	r.exportICS(cmd, []string{"work.ics"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncImportTimeclock(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	r.DryRunOpt = true
+
+	m.On("ImportTimeclock", "work.timeclock", true).Return()
+	r.importTimeclock(cmd, []string{"work.timeclock"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncExportTimeclock(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ExportTimeclock", "").Return()
+	r.exportTimeclock(cmd, []string{})
+	m.On("ExportTimeclock", "work.timeclock").Return()
+	r.exportTimeclock(cmd, []string{"work.timeclock"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncExportXLSX(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("ExportXLSX", "work.xlsx").Return()
+	r.exportXLSX(cmd, []string{"work.xlsx"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncMigrate(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	r.FromOpt = "yaml:timesheet.yaml"
+	r.ToOpt = "sqlite:timesheet.db"
+
+	m.On("Migrate", "yaml:timesheet.yaml", "sqlite:timesheet.db").Return()
+	r.migrate(cmd, []string{})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncDoctor(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("Doctor", false).Return()
+	r.doctor(cmd, []string{})
+	r.FixOpt = true
+	m.On("Doctor", true).Return()
+	r.doctor(cmd, []string{})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncUndoRedo(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("Undo", 1).Return()
+	r.undo(cmd, []string{})
+	m.On("Undo", 3).Return()
+	r.undo(cmd, []string{"3"})
+	m.On("Redo", 2).Return()
+	r.redo(cmd, []string{"2"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncHistory(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("History", "").Return()
+	r.history(cmd, []string{})
+	m.On("History", "2026-09-07").Return()
+	r.history(cmd, []string{"2026-09-07"})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncSync(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("Sync").Return()
+	r.sync(cmd, []string{})
+	m.AssertExpectations(t)
+}
+
+func TestRunFuncMerge(t *testing.T) {
+	var m = &RunnerMock{}
+
+	var r = New(m)
+
+	var cmd = &cobra.Command{}
+
+	m.On("Merge", "base.yaml", "ours.yaml", "theirs.yaml").Return()
+	r.merge(cmd, []string{"base.yaml", "ours.yaml", "theirs.yaml"})
+	m.AssertExpectations(t)
 }
@@ -9,6 +9,7 @@ require (
 	github.com/olekukonko/tablewriter v0.0.3
 	github.com/spf13/cobra v0.0.5
 	github.com/stretchr/testify v1.2.2
-	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550 // indirect
+	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550
 	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v3 v3.0.1
 )
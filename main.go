@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"git.sr.ht/~hjertnes/timesheet/cmd"
 	"git.sr.ht/~hjertnes/timesheet/models"
@@ -11,34 +13,46 @@ import (
 	"git.sr.ht/~hjertnes/timesheet/utils"
 )
 
+// storage is the backend and file the document is kept in, set with TIMESHEET_STORAGE
+// as yaml:<file>, encrypted:<file> or sqlite:<file>
+func storage() string {
+	if spec := os.Getenv("TIMESHEET_STORAGE"); spec != "" {
+		return spec
+	}
+
+	return fmt.Sprintf("yaml:%s/txt/timesheet.yaml", os.Getenv("HOME"))
+}
+
 func main() {
-	home := os.Getenv("HOME")
-	filename := fmt.Sprintf("%s/txt/timesheet.yaml", home)
-
-	if _, err := os.Stat(filename); err != nil && os.IsNotExist(err) {
-		repo := models.New(filename)
-		d := &models.Document{
-			Configuration: map[string]string{
-				"workday": "450",
-				"break": "30",
-			},
-			Items: map[string]map[string]models.DayItem{},
-		}
+	repo, err := models.Open(storage())
+	utils.ErrorHandler(err)
 
-		err = repo.Save(d)
-		utils.ErrorHandler(err)
-	}
+	repo = models.NewJournal(repo, models.Path(storage()), strings.Join(os.Args[1:], " "))
 
-	repo := models.New(filename)
 	d, err := repo.Load()
 	utils.ErrorHandler(err)
+
+	if d.Configuration == nil && len(d.Items) == 0 {
+		d.Configuration = map[string]string{
+			"workday": "450",
+			"break":   "30",
+		}
+	}
+
 	if d.Items == nil {
 		d.Items = make(map[string]map[string]models.DayItem)
 	}
 
+	location, err := runner.Location(d)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s, the local time zone is used until the timezone setting is fixed\n", err)
+	}
+
+	time.Local = location
+
 	var rr = read.New()
 
-	var r = runner.New(d, rr)
+	var r = runner.New(d, rr, repo)
 
 	var rf = cmd.New(r)
 
@@ -46,4 +60,6 @@ func main() {
 
 	err = repo.Save(d)
 	utils.ErrorHandler(err)
+
+	r.Commit(strings.Join(os.Args[1:], " "))
 }
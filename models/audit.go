@@ -0,0 +1,119 @@
+package models
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Auditor is a repository that keeps a log of every change that was saved
+type Auditor interface {
+	History() ([]Change, error)
+}
+
+// auditRecord is a change in an audit log, or the change encrypted on its own when the document is encrypted
+type auditRecord struct {
+	Change `yaml:",inline"`
+	Sealed string `yaml:"sealed,omitempty"`
+}
+
+// sealedRecord is how an encrypted change is written, without any of the change in the clear
+type sealedRecord struct {
+	Sealed string `yaml:"sealed"`
+}
+
+// appendAudit adds a change to the end of an audit log, a yaml document per change.
+// The log is only ever added to
+func appendAudit(filename string, change *Change, seal func(content []byte) ([]byte, error)) error {
+	content, err := yaml.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := seal(content)
+	if err != nil {
+		return err
+	}
+
+	if encrypted(sealed) {
+		content, err = yaml.Marshal(sealedRecord{Sealed: base64.StdEncoding.EncodeToString(sealed)})
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append([]byte("---\n"), content...)); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// readAudit reads every change of an audit log, the oldest first
+func readAudit(filename string, unseal func(content []byte) ([]byte, error)) ([]Change, error) {
+	var result = make([]Change, 0)
+
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var decoder = yaml.NewDecoder(f)
+
+	for {
+		var record auditRecord
+
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return result, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if record.Sealed != "" {
+			if record.Change, err = unsealChange(record.Sealed, unseal); err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, record.Change)
+	}
+}
+
+func unsealChange(sealed string, unseal func(content []byte) ([]byte, error)) (Change, error) {
+	var change Change
+
+	content, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return change, err
+	}
+
+	if content, err = unseal(content); err != nil {
+		return change, err
+	}
+
+	err = yaml.Unmarshal(content, &change)
+
+	return change, err
+}
+
+// History returns every change that was saved, the oldest first
+func (j *journal) History() ([]Change, error) {
+	return readAudit(j.auditFile, j.unseal)
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// The ways worked time is rounded, and what is rounded
+const (
+	RoundingNone    = "none"
+	RoundingNearest = "nearest"
+	RoundingUp      = "up"
+	RoundingDown    = "down"
+
+	RoundingScopeEvent   = "event"
+	RoundingScopeDay     = "day"
+	RoundingScopeProject = "project"
+)
+
+// CheckRounding checks a rounding rule, the settings and the clients are held to the same rules
+func CheckRounding(mode string, minutes int, scope string) error {
+	if mode != RoundingNone && mode != RoundingNearest && mode != RoundingUp && mode != RoundingDown {
+		return fmt.Errorf("unknown rounding %s", mode)
+	}
+
+	if minutes <= 0 {
+		return fmt.Errorf("rounding_minutes must be larger than 0")
+	}
+
+	if scope != RoundingScopeEvent && scope != RoundingScopeDay && scope != RoundingScopeProject {
+		return fmt.Errorf("unknown rounding_scope %s", scope)
+	}
+
+	return nil
+}
+
+// Client is who projects are billed to
+type Client struct {
+	Name            string  `yaml:"name"`
+	Address         string  `yaml:"address,omitempty"`
+	VAT             string  `yaml:"vat,omitempty"`
+	Rate            float64 `yaml:"rate,omitempty"`
+	Currency        string  `yaml:"currency,omitempty"`
+	Rounding        string  `yaml:"rounding,omitempty"`
+	RoundingMinutes int     `yaml:"rounding_minutes,omitempty"`
+	RoundingScope   string  `yaml:"rounding_scope,omitempty"`
+}
+
+// check checks the rate and rounding of the client, the rounding it doesn't set comes from the settings
+func (c *Client) check() error {
+	if c.Rate < 0 {
+		return errors.New("rate can't be negative")
+	}
+
+	if c.Rate > 0 && c.Currency == "" {
+		return errors.New("a client with a rate needs a currency")
+	}
+
+	var mode, minutes, scope = c.Rounding, c.RoundingMinutes, c.RoundingScope
+
+	if mode == "" {
+		mode = RoundingNone
+	}
+
+	if c.RoundingMinutes == 0 {
+		minutes = 1
+	}
+
+	if scope == "" {
+		scope = RoundingScopeDay
+	}
+
+	return CheckRounding(mode, minutes, scope)
+}
+
+// Set updates a field of the client from a string, the client is left as it was when the value is wrong
+func (c *Client) Set(field string, value string) error {
+	var changed = *c
+
+	if err := changed.set(field, value); err != nil {
+		return err
+	}
+
+	if err := changed.check(); err != nil {
+		return err
+	}
+
+	*c = changed
+
+	return nil
+}
+
+func (c *Client) set(field string, value string) error {
+	switch field {
+	case "address":
+		c.Address = value
+	case "vat":
+		c.VAT = value
+	case "rate":
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+
+		if rate < 0 {
+			return errors.New("rate can't be negative")
+		}
+
+		c.Rate = rate
+	case "currency":
+		c.Currency = value
+	case "rounding":
+		c.Rounding = value
+	case "rounding_minutes":
+		minutes, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+
+		c.RoundingMinutes = minutes
+	case "rounding_scope":
+		c.RoundingScope = value
+	default:
+		return fmt.Errorf("unknown client field %s", field)
+	}
+
+	return nil
+}
+
+// Client finds a client by name
+func (d *Document) Client(name string) (*Client, bool) {
+	for i := range d.Clients {
+		if d.Clients[i].Name == name {
+			return &d.Clients[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// AddClient adds a client
+func (d *Document) AddClient(client Client) error {
+	if client.Name == "" {
+		return errors.New("client needs a name")
+	}
+
+	if _, ok := d.Client(client.Name); ok {
+		return fmt.Errorf("client %s already exists", client.Name)
+	}
+
+	if err := client.check(); err != nil {
+		return err
+	}
+
+	d.Clients = append(d.Clients, client)
+
+	return nil
+}
+
+// EditClient updates a field of a client
+func (d *Document) EditClient(name string, field string, value string) error {
+	client, ok := d.Client(name)
+	if !ok {
+		return fmt.Errorf("client %s not found", name)
+	}
+
+	return client.Set(field, value)
+}
+
+// RemoveClient removes a client that no project is linked to
+func (d *Document) RemoveClient(name string) error {
+	for _, project := range d.Projects {
+		if project.Client == name {
+			return fmt.Errorf("project %s is linked to client %s", project.Name, name)
+		}
+	}
+
+	for i, client := range d.Clients {
+		if client.Name == name {
+			d.Clients = append(d.Clients[:i], d.Clients[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("client %s not found", name)
+}
+
+// ClientFor finds the client an event on a day is billed to, from its project or else its rate
+func (d *Document) ClientFor(event EventItem, day string) string {
+	if project, ok := d.Project(event.Project); ok && project.Client != "" {
+		return project.Client
+	}
+
+	rate, _ := d.RateFor(event.Project, day)
+
+	return rate.Client
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClients(t *testing.T) {
+	d := Document{}
+
+	assert.Nil(t, d.AddClient(Client{Name: "acme", Rate: 1000, Currency: "NOK"}))
+	assert.NotNil(t, d.AddClient(Client{Name: "acme"}))
+	assert.NotNil(t, d.AddClient(Client{}))
+	assert.NotNil(t, d.AddClient(Client{Name: "cheap", Rate: -1}))
+	assert.NotNil(t, d.AddClient(Client{Name: "nocurrency", Rate: 1}))
+	assert.NotNil(t, d.AddClient(Client{Name: "sloppy", Rounding: "sideways"}))
+	assert.NotNil(t, d.AddClient(Client{Name: "sloppy", RoundingMinutes: -5}))
+	assert.NotNil(t, d.AddClient(Client{Name: "sloppy", RoundingScope: "week"}))
+	assert.Nil(t, d.AddClient(Client{Name: "careful", Rounding: "nearest", RoundingMinutes: 30, RoundingScope: "project"}))
+
+	assert.Nil(t, d.EditClient("acme", "address", "Street 1\nOslo"))
+	assert.Nil(t, d.EditClient("acme", "vat", "NO123"))
+	assert.Nil(t, d.EditClient("acme", "rate", "1100"))
+	assert.Nil(t, d.EditClient("acme", "currency", "EUR"))
+	assert.Nil(t, d.EditClient("acme", "rounding", "up"))
+	assert.Nil(t, d.EditClient("acme", "rounding_minutes", "6"))
+	assert.Nil(t, d.EditClient("acme", "rounding_scope", "event"))
+	assert.NotNil(t, d.EditClient("acme", "rate", "-1"))
+	assert.NotNil(t, d.EditClient("acme", "rate", "a lot"))
+	assert.NotNil(t, d.EditClient("acme", "rounding_minutes", "six"))
+	assert.NotNil(t, d.EditClient("acme", "rounding_minutes", "-6"))
+	assert.NotNil(t, d.EditClient("acme", "rounding", "sideways"))
+	assert.NotNil(t, d.EditClient("acme", "rounding_scope", "week"))
+	assert.NotNil(t, d.EditClient("acme", "colour", "red"))
+	assert.NotNil(t, d.EditClient("other", "vat", "NO123"))
+	assert.NotNil(t, d.EditClient("acme", "currency", ""))
+
+	assert.Nil(t, d.AddClient(Client{Name: "free"}))
+	assert.NotNil(t, d.EditClient("free", "rate", "900"))
+	assert.Nil(t, d.EditClient("free", "currency", "NOK"))
+	assert.Nil(t, d.EditClient("free", "rate", "900"))
+	assert.Nil(t, d.RemoveClient("free"))
+
+	client, ok := d.Client("acme")
+	assert.True(t, ok)
+	assert.Equal(t, Client{
+		Name:            "acme",
+		Address:         "Street 1\nOslo",
+		VAT:             "NO123",
+		Rate:            1100,
+		Currency:        "EUR",
+		Rounding:        "up",
+		RoundingMinutes: 6,
+		RoundingScope:   "event",
+	}, *client)
+
+	assert.NotNil(t, d.AddProject(Project{Name: "a", Client: "other"}))
+	assert.Nil(t, d.AddProject(Project{Name: "a", Client: "acme"}))
+	assert.Nil(t, d.AddProject(Project{Name: "b"}))
+	assert.NotNil(t, d.EditProject("b", "client", "other"))
+	assert.NotNil(t, d.EditProject("c", "client", "acme"))
+
+	assert.Nil(t, d.AddRate(Rate{Project: "a", Amount: 1200, Currency: "EUR", From: "2026-07-01"}))
+	assert.Nil(t, d.AddRate(Rate{Project: "b", Client: "someone", Amount: 500, Currency: "EUR"}))
+
+	rate, ok := d.RateFor("a", "2026-06-01")
+	assert.True(t, ok)
+	assert.Equal(t, 1100.0, rate.Amount)
+	assert.Equal(t, "acme", rate.Client)
+
+	rate, _ = d.RateFor("a", "2026-07-01")
+	assert.Equal(t, 1200.0, rate.Amount)
+	assert.Equal(t, "acme", rate.Client)
+
+	assert.Equal(t, "acme", d.ClientFor(EventItem{Project: "a"}, "2026-07-01"))
+	assert.Equal(t, "someone", d.ClientFor(EventItem{Project: "b"}, "2026-07-01"))
+
+	assert.NotNil(t, d.RemoveClient("acme"))
+	assert.Nil(t, d.EditProject("a", "client", ""))
+	assert.Nil(t, d.RemoveClient("acme"))
+	assert.NotNil(t, d.RemoveClient("acme"))
+}
+
+func TestRatesByClient(t *testing.T) {
+	d := Document{}
+
+	assert.Nil(t, d.AddClient(Client{Name: "acme"}))
+	assert.Nil(t, d.AddClient(Client{Name: "globex"}))
+	assert.Nil(t, d.AddClient(Client{Name: "initech", Rate: 700, Currency: "NOK"}))
+	assert.Nil(t, d.AddProject(Project{Name: "a", Client: "acme"}))
+	assert.Nil(t, d.AddProject(Project{Name: "g", Client: "globex"}))
+	assert.Nil(t, d.AddProject(Project{Name: "i", Client: "initech"}))
+
+	assert.Nil(t, d.AddRate(Rate{Client: "acme", Amount: 1000, Currency: "NOK"}))
+	assert.Nil(t, d.AddRate(Rate{Client: "globex", Amount: 1500, Currency: "NOK"}))
+	assert.NotNil(t, d.AddRate(Rate{Client: "globex", Amount: 1600, Currency: "NOK"}))
+	assert.Nil(t, d.AddRate(Rate{Amount: 800, Currency: "NOK"}))
+
+	rate, ok := d.RateFor("a", "2026-03-01")
+	assert.True(t, ok)
+	assert.Equal(t, 1000.0, rate.Amount)
+	assert.Equal(t, "acme", rate.Client)
+
+	rate, _ = d.RateFor("g", "2026-03-01")
+	assert.Equal(t, 1500.0, rate.Amount)
+	assert.Equal(t, "globex", rate.Client)
+
+	rate, _ = d.RateFor("i", "2026-03-01")
+	assert.Equal(t, 700.0, rate.Amount)
+
+	rate, _ = d.RateFor("other", "2026-03-01")
+	assert.Equal(t, 800.0, rate.Amount)
+	assert.Equal(t, "", rate.Client)
+
+	assert.Nil(t, d.AddRate(Rate{Project: "g", Amount: 2000, Currency: "NOK", From: "2026-07-01"}))
+
+	rate, _ = d.RateFor("g", "2026-07-01")
+	assert.Equal(t, 2000.0, rate.Amount)
+	assert.Equal(t, "globex", rate.Client)
+}
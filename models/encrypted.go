@@ -0,0 +1,239 @@
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// encryptedHeader starts every encrypted file, it is followed by the salt, the nonce and the sealed content
+const encryptedHeader = "timesheet encrypted v1\n"
+
+const (
+	saltSize  = 16
+	nonceSize = 24
+)
+
+// Passphrase returns the passphrase of an encrypted file, confirm is set when it is used for the first time
+type Passphrase func(confirm bool) ([]byte, error)
+
+// Sealer is a repository that can encrypt what is kept next to it the way it encrypts the document
+type Sealer interface {
+	Seal(content []byte) ([]byte, error)
+	Unseal(content []byte) ([]byte, error)
+}
+
+// crypt encrypts with secretbox and a key derived from a passphrase with scrypt. The salt is kept
+// between saves, so the key is only derived once. Content that was decrypted or encrypted before
+// is encrypted the same way again, so saving what didn't change leaves the file as it was
+type crypt struct {
+	passphrase Passphrase
+	secret     []byte
+	salt       []byte
+	keys       map[string]*[32]byte
+	sealed     map[[sha256.Size]byte][]byte
+}
+
+func encrypted(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(encryptedHeader))
+}
+
+func (c *crypt) key(salt []byte, confirm bool) (*[32]byte, error) {
+	if key, ok := c.keys[string(salt)]; ok {
+		return key, nil
+	}
+
+	if c.secret == nil {
+		secret, err := c.passphrase(confirm)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(secret) == 0 {
+			return nil, errors.New("the passphrase can't be empty")
+		}
+
+		c.secret = secret
+	}
+
+	derived, err := scrypt.Key(c.secret, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	var key [32]byte
+	copy(key[:], derived)
+
+	if c.keys == nil {
+		c.keys = make(map[string]*[32]byte)
+	}
+
+	c.keys[string(salt)] = &key
+
+	return &key, nil
+}
+
+func (c *crypt) remember(content []byte, sealed []byte) {
+	if c.sealed == nil {
+		c.sealed = make(map[[sha256.Size]byte][]byte)
+	}
+
+	c.sealed[sha256.Sum256(content)] = sealed
+}
+
+func (c *crypt) seal(content []byte) ([]byte, error) {
+	if sealed, ok := c.sealed[sha256.Sum256(content)]; ok {
+		return sealed, nil
+	}
+
+	var confirm = c.salt == nil
+
+	if confirm {
+		c.salt = make([]byte, saltSize)
+
+		if _, err := io.ReadFull(rand.Reader, c.salt); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := c.key(c.salt, confirm)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [nonceSize]byte
+
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, err
+	}
+
+	var result = append([]byte(encryptedHeader), c.salt...)
+	result = append(result, nonce[:]...)
+
+	result = secretbox.Seal(result, content, &nonce, key)
+
+	c.remember(content, result)
+
+	return result, nil
+}
+
+func (c *crypt) unseal(sealed []byte) ([]byte, error) {
+	var content = sealed[len(encryptedHeader):]
+
+	if len(content) < saltSize+nonceSize+secretbox.Overhead {
+		return nil, errors.New("the encrypted content is cut short")
+	}
+
+	var salt = content[:saltSize]
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], content[saltSize:saltSize+nonceSize])
+
+	key, err := c.key(salt, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := secretbox.Open(nil, content[saltSize+nonceSize:], &nonce, key)
+	if !ok {
+		return nil, errors.New("can't decrypt, the passphrase is wrong or the file is damaged")
+	}
+
+	if c.salt == nil {
+		c.salt = append([]byte{}, salt...)
+	}
+
+	if bytes.Equal(salt, c.salt) {
+		c.remember(result, append([]byte{}, sealed...))
+	}
+
+	return result, nil
+}
+
+// Seal encrypts content when the repository is encrypted
+func (r *repository) Seal(content []byte) ([]byte, error) {
+	if r.crypt == nil {
+		return content, nil
+	}
+
+	return r.crypt.seal(content)
+}
+
+// Unseal decrypts content that is encrypted, other content is returned as it is
+func (r *repository) Unseal(content []byte) ([]byte, error) {
+	if !encrypted(content) {
+		return content, nil
+	}
+
+	if r.crypt == nil {
+		return nil, fmt.Errorf("%s is encrypted, open it as encrypted:%s", r.filename, r.filename)
+	}
+
+	return r.crypt.unseal(content)
+}
+
+// EnvironmentPassphrase reads the passphrase from TIMESHEET_KEY, the file in TIMESHEET_KEY_FILE
+// or asks for it when neither is set
+func EnvironmentPassphrase(confirm bool) ([]byte, error) {
+	if key := os.Getenv("TIMESHEET_KEY"); key != "" {
+		return []byte(key), nil
+	}
+
+	if filename := os.Getenv("TIMESHEET_KEY_FILE"); filename != "" {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(strings.TrimRight(string(content), "\r\n")), nil
+	}
+
+	var fd = int(os.Stdin.Fd())
+
+	if !terminal.IsTerminal(fd) {
+		return nil, errors.New("the data is encrypted, set TIMESHEET_KEY or TIMESHEET_KEY_FILE")
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+
+	secret, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil || !confirm {
+		return secret, err
+	}
+
+	fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+
+	repeated, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(secret, repeated) {
+		return nil, errors.New("the passphrases don't match")
+	}
+
+	return secret, nil
+}
+
+// NewEncrypted is a yaml repository that is encrypted with a passphrase, a file that isn't encrypted yet
+// is encrypted when it is saved
+func NewEncrypted(filename string, passphrase Passphrase) Repository {
+	return &repository{
+		filename: filename,
+		crypt:    &crypt{passphrase: passphrase},
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncrypted(t *testing.T) {
+	var filename = "/tmp/timesheet-encrypted.yaml"
+
+	for _, name := range []string{filename, filename + ".journal", filename + ".audit"} {
+		os.Remove(name)
+
+		defer os.Remove(name)
+	}
+
+	var asked = make([]bool, 0)
+
+	var passphrase = func(secret string) Passphrase {
+		return func(confirm bool) ([]byte, error) {
+			asked = append(asked, confirm)
+			return []byte(secret), nil
+		}
+	}
+
+	d := Document{Configuration: map[string]string{"workday": "450"}, Items: make(map[string]map[string]DayItem)}
+	d.AddEvent(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), false, false, EventItem{Note: "salary talks"})
+	assert.Nil(t, New(filename).Save(&d))
+
+	var repository = NewJournal(NewEncrypted(filename, passphrase("secret")), filename, "off 2026-09-07")
+
+	loaded, err := repository.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, d.Items, loaded.Items)
+
+	loaded.AddEvent(time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), false, true, EventItem{})
+	assert.Nil(t, repository.Save(loaded))
+	assert.Nil(t, repository.Save(loaded))
+	assert.Equal(t, []bool{true}, asked)
+
+	for _, name := range []string{filename, filename + ".journal", filename + ".audit"} {
+		content, err := ioutil.ReadFile(name)
+		assert.Nil(t, err)
+		assert.False(t, strings.Contains(string(content), "salary talks"), name)
+	}
+
+	content, _ := ioutil.ReadFile(filename)
+	assert.True(t, encrypted(content))
+
+	content, _ = ioutil.ReadFile(filename + ".audit")
+	assert.False(t, strings.Contains(string(content), "time:"))
+	assert.False(t, strings.Contains(string(content), "command:"))
+
+	repository = NewJournal(NewEncrypted(filename, passphrase("secret")), filename, "list")
+
+	loaded, err = repository.Load()
+	assert.Nil(t, err)
+	assert.Len(t, loaded.Items["2026"]["2026-09-07"].Events, 0)
+	assert.Equal(t, []bool{true, false}, asked)
+
+	history, err := repository.(Auditor).History()
+	assert.Nil(t, err)
+	assert.Equal(t, "salary talks", history[0].Days["2026-09-07"].Before.Events[0].Note)
+
+	changes, err := repository.(Undoer).Undo(loaded, 1)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+
+	var unchanged = NewEncrypted(filename, passphrase("secret"))
+
+	content, _ = ioutil.ReadFile(filename)
+	loaded, err = unchanged.Load()
+	assert.Nil(t, err)
+	assert.Nil(t, unchanged.Save(loaded))
+
+	saved, _ := ioutil.ReadFile(filename)
+	assert.Equal(t, content, saved)
+
+	loaded.Configuration["workday"] = "420"
+	assert.Nil(t, unchanged.Save(loaded))
+
+	saved, _ = ioutil.ReadFile(filename)
+	assert.NotEqual(t, content, saved)
+
+	_, err = NewEncrypted(filename, passphrase("wrong")).Load()
+	assert.NotNil(t, err)
+
+	_, err = New(filename).Load()
+	assert.NotNil(t, err)
+
+	r, err := Open("encrypted:" + filename)
+	assert.Nil(t, err)
+	assert.Equal(t, filename, r.(Filer).File())
+}
@@ -0,0 +1,402 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// journalSize is how many changes can be undone
+const journalSize = 100
+
+// DayChange is a day before and after a change, nil when the day didn't exist
+type DayChange struct {
+	Before *DayItem `yaml:"before,omitempty"`
+	After  *DayItem `yaml:"after,omitempty"`
+}
+
+// Change is what one command changed. Days are kept by date, the rest of the document
+// is kept as a whole without its days when any of it changed
+type Change struct {
+	Time    time.Time            `yaml:"time"`
+	Command string               `yaml:"command"`
+	Days    map[string]DayChange `yaml:"days,omitempty"`
+	Before  *Document            `yaml:"before,omitempty"`
+	After   *Document            `yaml:"after,omitempty"`
+}
+
+// rest returns everything in a document except its days
+func rest(d *Document) *Document {
+	return &Document{
+		Configuration: d.Configuration,
+		Overtime:      d.Overtime,
+		Clients:       d.Clients,
+		Projects:      d.Projects,
+		Rates:         d.Rates,
+		Invoices:      d.Invoices,
+		LastInvoice:   d.LastInvoice,
+	}
+}
+
+// clone copies a document, so changes to it don't change the original
+func clone(d *Document) (*Document, error) {
+	content, err := yaml.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Document
+
+	if err := yaml.Unmarshal(content, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Diff returns what changed from before to after, or nil when nothing did
+func Diff(before *Document, after *Document) (*Change, error) {
+	var change = Change{Days: make(map[string]DayChange)}
+
+	var old, current = flatten(before), flatten(after)
+
+	for day, item := range old {
+		var item = item
+
+		if now, ok := current[day]; !ok {
+			change.Days[day] = DayChange{Before: &item}
+		} else if !reflect.DeepEqual(item, now) {
+			change.Days[day] = DayChange{Before: &item, After: &now}
+		}
+	}
+
+	for day, item := range current {
+		var item = item
+
+		if _, ok := old[day]; !ok {
+			change.Days[day] = DayChange{After: &item}
+		}
+	}
+
+	oldRest, err := yaml.Marshal(rest(before))
+	if err != nil {
+		return nil, err
+	}
+
+	currentRest, err := yaml.Marshal(rest(after))
+	if err != nil {
+		return nil, err
+	}
+
+	if string(oldRest) != string(currentRest) {
+		if change.Before, err = clone(rest(before)); err != nil {
+			return nil, err
+		}
+
+		if change.After, err = clone(rest(after)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(change.Days) == 0 && change.Before == nil {
+		return nil, nil
+	}
+
+	return &change, nil
+}
+
+// setDay replaces a day of a document, or removes it when item is nil
+func setDay(d *Document, day string, item *DayItem) {
+	var year = day[:4]
+
+	if item == nil {
+		delete(d.Items[year], day)
+
+		if len(d.Items[year]) == 0 {
+			delete(d.Items, year)
+		}
+
+		return
+	}
+
+	if d.Items == nil {
+		d.Items = make(map[string]map[string]DayItem)
+	}
+
+	if _, ok := d.Items[year]; !ok {
+		d.Items[year] = make(map[string]DayItem)
+	}
+
+	d.Items[year][day] = copyDay(*item)
+}
+
+// apply turns a document as it was before the change into what it was after it, or back when reverting.
+// Nothing is changed when a day was changed since. The last invoice number is never turned back
+func (c *Change) apply(d *Document, revert bool) error {
+	var current = flatten(d)
+
+	for day, change := range c.Days {
+		var from = change.Before
+		if revert {
+			from = change.After
+		}
+
+		if now, ok := current[day]; ok != (from != nil) || (ok && !reflect.DeepEqual(now, copyDay(*from))) {
+			return fmt.Errorf("%s has changed since %s", day, c.Command)
+		}
+	}
+
+	for day, change := range c.Days {
+		var to = change.After
+		if revert {
+			to = change.Before
+		}
+
+		setDay(d, day, to)
+	}
+
+	var to = c.After
+	if revert {
+		to = c.Before
+	}
+
+	if to != nil {
+		d.Configuration = to.Configuration
+		d.Overtime = to.Overtime
+		d.Clients = to.Clients
+		d.Projects = to.Projects
+		d.Rates = to.Rates
+		d.Invoices = to.Invoices
+
+		if to.LastInvoice > d.LastInvoice {
+			d.LastInvoice = to.LastInvoice
+		}
+	}
+
+	return nil
+}
+
+// Undoer is a repository that keeps the changes that were saved so they can be undone
+type Undoer interface {
+	Undo(d *Document, n int) ([]Change, error)
+	Redo(d *Document, n int) ([]Change, error)
+}
+
+type journalFile struct {
+	Done   []Change `yaml:"done"`
+	Undone []Change `yaml:"undone"`
+}
+
+type journal struct {
+	Repository
+	changesFile string
+	auditFile   string
+	command     string
+	loaded      *Document
+	changes     *journalFile
+	moved       bool
+	now         func() time.Time
+}
+
+// NewJournal keeps every change saved to the repository of filename as the change made by command.
+// The last changes are kept in <filename>.journal so they can be undone and redone, and every change
+// is added to the end of <filename>.audit
+func NewJournal(repository Repository, filename string, command string) Repository {
+	return &journal{
+		Repository:  repository,
+		changesFile: filename + ".journal",
+		auditFile:   filename + ".audit",
+		command:     command,
+		now:         time.Now,
+	}
+}
+
+func (j *journal) read() error {
+	if j.changes != nil {
+		return nil
+	}
+
+	j.changes = &journalFile{}
+
+	content, err := ioutil.ReadFile(j.changesFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if content, err = j.unseal(content); err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(content, j.changes)
+}
+
+// seal encrypts what is kept next to the document the way the document is, if it is
+func (j *journal) seal(content []byte) ([]byte, error) {
+	if sealer, ok := j.Repository.(Sealer); ok {
+		return sealer.Seal(content)
+	}
+
+	return content, nil
+}
+
+func (j *journal) unseal(content []byte) ([]byte, error) {
+	if sealer, ok := j.Repository.(Sealer); ok {
+		return sealer.Unseal(content)
+	}
+
+	return content, nil
+}
+
+func (j *journal) Load() (*Document, error) {
+	d, err := j.Repository.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if j.loaded, err = clone(d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Save saves the document and keeps what changed since it was loaded. A new change can't be redone over,
+// undoing and redoing are only added to the audit log. A document that didn't change isn't saved,
+// so commands that only read leave the file as it was. Both are sorted before they are compared, the way
+// they are saved, so changes can be undone and events that are only out of order aren't a change
+func (j *journal) Save(d *Document) error {
+	if j.loaded == nil {
+		return j.Repository.Save(d)
+	}
+
+	before, err := clone(j.loaded)
+	if err != nil {
+		return err
+	}
+
+	before.Sort()
+	d.Sort()
+
+	change, err := Diff(before, d)
+	if err != nil {
+		return err
+	}
+
+	if change == nil && !j.moved {
+		return nil
+	}
+
+	if err := j.Repository.Save(d); err != nil {
+		return err
+	}
+
+	if err := j.read(); err != nil {
+		return err
+	}
+
+	if change != nil {
+		change.Time = j.now()
+		change.Command = j.command
+
+		if err := appendAudit(j.auditFile, change, j.seal); err != nil {
+			return err
+		}
+	}
+
+	if change != nil && !j.moved {
+		j.changes.Done = append(j.changes.Done, *change)
+		j.changes.Undone = nil
+
+		if len(j.changes.Done) > journalSize {
+			j.changes.Done = j.changes.Done[len(j.changes.Done)-journalSize:]
+		}
+	}
+
+	if j.loaded, err = clone(d); err != nil {
+		return err
+	}
+
+	j.moved = false
+
+	content, err := yaml.Marshal(j.changes)
+	if err != nil {
+		return err
+	}
+
+	if content, err = j.seal(content); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(j.changesFile, content, 0600)
+}
+
+// move takes up to n changes from the end of one list, applies them to the document and puts them on the other
+func (j *journal) move(d *Document, n int, from *[]Change, to *[]Change, revert bool) ([]Change, error) {
+	if j.loaded == nil {
+		return nil, errors.New("the document has to be loaded first")
+	}
+
+	var result = make([]Change, 0)
+
+	for len(result) < n && len(*from) > 0 {
+		var change = (*from)[len(*from)-1]
+
+		if err := change.apply(d, revert); err != nil {
+			return result, err
+		}
+
+		*from = (*from)[:len(*from)-1]
+		*to = append(*to, change)
+
+		result = append(result, change)
+	}
+
+	j.moved = j.moved || len(result) > 0
+
+	return result, nil
+}
+
+// Undo reverts the last n changes, the document is saved as usual afterwards
+func (j *journal) Undo(d *Document, n int) ([]Change, error) {
+	if err := j.read(); err != nil {
+		return nil, err
+	}
+
+	return j.move(d, n, &j.changes.Done, &j.changes.Undone, true)
+}
+
+// Redo makes the last n undone changes again
+func (j *journal) Redo(d *Document, n int) ([]Change, error) {
+	if err := j.read(); err != nil {
+		return nil, err
+	}
+
+	return j.move(d, n, &j.changes.Undone, &j.changes.Done, false)
+}
+
+// Locate finds the lines of the repository it keeps the changes of, if it can
+func (j *journal) Locate() (map[string]int, error) {
+	if locator, ok := j.Repository.(Locator); ok {
+		return locator.Locate()
+	}
+
+	return make(map[string]int), nil
+}
+
+// File is the file of the repository it keeps the changes of, if it is kept in one
+func (j *journal) File() string {
+	if filer, ok := j.Repository.(Filer); ok {
+		return filer.File()
+	}
+
+	return ""
+}
@@ -0,0 +1,239 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	before := Document{
+		Configuration: map[string]string{"workday": "450"},
+		Items:         make(map[string]map[string]DayItem),
+	}
+	before.AddEvent(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), false, false, EventItem{})
+	before.AddEvent(time.Date(2026, 9, 8, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 16, 0, 0, 0, time.UTC), false, false, EventItem{})
+
+	after, err := clone(&before)
+	assert.Nil(t, err)
+
+	change, err := Diff(&before, after)
+	assert.Nil(t, err)
+	assert.Nil(t, change)
+
+	after.AddEvent(time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), false, true, EventItem{})
+	delete(after.Items["2026"], "2026-09-08")
+	after.AddEvent(time.Date(2026, 9, 9, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 9, 12, 0, 0, 0, time.UTC), false, false, EventItem{})
+
+	change, err = Diff(&before, after)
+	assert.Nil(t, err)
+	assert.Len(t, change.Days, 3)
+	assert.Nil(t, change.Days["2026-09-08"].After)
+	assert.Nil(t, change.Days["2026-09-09"].Before)
+	assert.Equal(t, "16:00:00", change.Days["2026-09-07"].Before.Events[0].End)
+	assert.Len(t, change.Days["2026-09-07"].After.Events, 0)
+	assert.Nil(t, change.Before)
+
+	after.Configuration["workday"] = "480"
+
+	change, err = Diff(&before, after)
+	assert.Nil(t, err)
+	assert.Equal(t, "450", change.Before.Configuration["workday"])
+	assert.Equal(t, "480", change.After.Configuration["workday"])
+
+	assert.Nil(t, change.apply(&before, false))
+	assert.Equal(t, after.Items, before.Items)
+	assert.Equal(t, "480", before.Configuration["workday"])
+
+	assert.Nil(t, change.apply(&before, true))
+	assert.Len(t, before.Items["2026"], 2)
+	assert.Equal(t, "450", before.Configuration["workday"])
+
+	assert.NotNil(t, change.apply(&before, true))
+}
+
+func TestJournal(t *testing.T) {
+	var filename = "/tmp/timesheet-journal.yaml"
+
+	for _, name := range []string{filename, filename + ".journal", filename + ".audit"} {
+		os.Remove(name)
+
+		defer os.Remove(name)
+	}
+
+	var open = func(command string) (Repository, *Document) {
+		var repository = NewJournal(New(filename), filename, command)
+
+		d, err := repository.Load()
+		assert.Nil(t, err)
+
+		if d.Items == nil {
+			d.Items = make(map[string]map[string]DayItem)
+		}
+
+		return repository, d
+	}
+
+	repository, d := open("add 2026-09-07 08:00 16:00")
+	d.AddEvent(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), false, false, EventItem{})
+	assert.Nil(t, repository.Save(d))
+
+	repository, d = open("off 2026-09-07")
+	d.AddEvent(time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), false, true, EventItem{})
+	assert.Nil(t, repository.Save(d))
+	assert.Len(t, d.Items["2026"]["2026-09-07"].Events, 0)
+
+	repository, d = open("list")
+	assert.Nil(t, repository.Save(d))
+
+	repository, d = open("undo")
+	changes, err := repository.(Undoer).Undo(d, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "off 2026-09-07", changes[0].Command)
+	assert.Nil(t, repository.Save(d))
+
+	repository, d = open("list")
+	assert.Equal(t, "08:00:00", d.Items["2026"]["2026-09-07"].Events[0].Start)
+
+	changes, err = repository.(Undoer).Undo(d, 5)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Len(t, d.Items, 0)
+	assert.Nil(t, repository.Save(d))
+
+	repository, d = open("redo 2")
+	changes, err = repository.(Undoer).Redo(d, 2)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+	assert.Len(t, d.Items["2026"]["2026-09-07"].Events, 0)
+	assert.Nil(t, repository.Save(d))
+
+	repository, d = open("undo")
+	_, err = repository.(Undoer).Undo(d, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, repository.Save(d))
+
+	repository, d = open("add 2026-09-08 08:00 16:00")
+	d.AddEvent(time.Date(2026, 9, 8, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 16, 0, 0, 0, time.UTC), false, false, EventItem{})
+	assert.Nil(t, repository.Save(d))
+
+	repository, d = open("redo")
+	changes, err = repository.(Undoer).Redo(d, 1)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 0)
+
+	history, err := repository.(Auditor).History()
+	assert.Nil(t, err)
+
+	var commands = make([]string, 0)
+	for _, change := range history {
+		commands = append(commands, change.Command)
+	}
+
+	assert.Equal(t, []string{"add 2026-09-07 08:00 16:00", "off 2026-09-07", "undo", "list", "redo 2", "undo", "add 2026-09-08 08:00 16:00"}, commands)
+	assert.Len(t, history[1].Days["2026-09-07"].After.Events, 0)
+	assert.Equal(t, "08:00:00", history[1].Days["2026-09-07"].Before.Events[0].Start)
+	assert.False(t, history[0].Time.IsZero())
+}
+
+func TestJournalUnchanged(t *testing.T) {
+	var filename = "/tmp/timesheet-unchanged.yaml"
+
+	for _, name := range []string{filename, filename + ".journal", filename + ".audit"} {
+		os.Remove(name)
+
+		defer os.Remove(name)
+	}
+
+	var content = []byte("schema_version: 1\n\"2026\":\n  \"2026-09-07\":\n    excluded: false\n    events:\n    - start: \"8:00\"\n      end: \"9:00\"\n    - start: \"12:00:00\"\n      end: \"13:00:00\"\n")
+	assert.Nil(t, ioutil.WriteFile(filename, content, 0600))
+
+	var repository = NewJournal(New(filename), filename, "doctor")
+
+	d, err := repository.Load()
+	assert.Nil(t, err)
+	assert.Nil(t, repository.Save(d))
+
+	saved, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), string(saved))
+
+	_, err = os.Stat(filename + ".audit")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestJournalInvoice(t *testing.T) {
+	var filename = "/tmp/timesheet-invoice.yaml"
+
+	for _, name := range []string{filename, filename + ".journal", filename + ".audit"} {
+		os.Remove(name)
+
+		defer os.Remove(name)
+	}
+
+	var repository = NewJournal(New(filename), filename, "invoice")
+
+	d, err := repository.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, d.AddInvoice(Invoice{Client: "acme"}).Number)
+	assert.Nil(t, repository.Save(d))
+
+	repository = NewJournal(New(filename), filename, "undo")
+
+	d, err = repository.Load()
+	assert.Nil(t, err)
+
+	_, err = repository.(Undoer).Undo(d, 1)
+	assert.Nil(t, err)
+	assert.Len(t, d.Invoices, 0)
+	assert.Nil(t, repository.Save(d))
+
+	repository = NewJournal(New(filename), filename, "invoice")
+
+	d, err = repository.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, d.AddInvoice(Invoice{Client: "acme"}).Number)
+}
+
+func TestJournalOutOfOrder(t *testing.T) {
+	var filename = "/tmp/timesheet-out-of-order.yaml"
+
+	for _, name := range []string{filename, filename + ".journal", filename + ".audit"} {
+		os.Remove(name)
+
+		defer os.Remove(name)
+	}
+
+	for _, command := range []struct {
+		text       string
+		start, end int
+	}{
+		{"add 2026-09-07 10:00 12:30", 10, 12},
+		{"add 2026-09-07 08:00 09:30", 8, 9},
+	} {
+		var repository = NewJournal(New(filename), filename, command.text)
+
+		d, err := repository.Load()
+		assert.Nil(t, err)
+
+		if d.Items == nil {
+			d.Items = make(map[string]map[string]DayItem)
+		}
+
+		d.AddEvent(time.Date(2026, 9, 7, command.start, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, command.end, 30, 0, 0, time.UTC), false, false, EventItem{})
+		assert.Nil(t, repository.Save(d))
+	}
+
+	var repository = NewJournal(New(filename), filename, "undo 2")
+
+	d, err := repository.Load()
+	assert.Nil(t, err)
+
+	changes, err := repository.(Undoer).Undo(d, 2)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+	assert.Len(t, d.Items, 0)
+}
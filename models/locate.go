@@ -0,0 +1,73 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	yamlnode "gopkg.in/yaml.v3"
+)
+
+// Locator is implemented by storages that can tell which line of their file a value is on
+type Locator interface {
+	Locate() (map[string]int, error)
+}
+
+// Lines maps the path of every value in a yaml document, its keys and indexes joined by /
+// like 2019/2019-03-04/events/0/start, to the line it is on
+func Lines(content []byte) (map[string]int, error) {
+	var root yamlnode.Node
+
+	if err := yamlnode.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+
+	var result = make(map[string]int)
+
+	var walk func(node *yamlnode.Node, path []string)
+
+	walk = func(node *yamlnode.Node, path []string) {
+		switch node.Kind {
+		case yamlnode.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+		case yamlnode.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				var current = append(append([]string{}, path...), node.Content[i].Value)
+				result[strings.Join(current, "/")] = node.Content[i].Line
+				walk(node.Content[i+1], current)
+			}
+		case yamlnode.SequenceNode:
+			for i, child := range node.Content {
+				var current = append(append([]string{}, path...), strconv.Itoa(i))
+				result[strings.Join(current, "/")] = child.Line
+				walk(child, current)
+			}
+		}
+	}
+
+	walk(&root, nil)
+
+	return result, nil
+}
+
+// Locate reads the lines of the values in the file
+func (r *repository) Locate() (map[string]int, error) {
+	content, err := ioutil.ReadFile(r.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]int{}, nil
+		}
+
+		return nil, err
+	}
+
+	content, err = r.Unseal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return Lines(content)
+}
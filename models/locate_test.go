@@ -0,0 +1,23 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	lines, err := Lines([]byte("configuration:\n  workday: \"450\"\n\"2019\":\n  \"2019-03-04\":\n    events:\n    - start: \"08:00:00\"\n      end: \"12:00:00\"\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, lines["configuration/workday"])
+	assert.Equal(t, 4, lines["2019/2019-03-04"])
+	assert.Equal(t, 6, lines["2019/2019-03-04/events/0"])
+	assert.Equal(t, 7, lines["2019/2019-03-04/events/0/end"])
+
+	_, err = Lines([]byte("a: [b"))
+	assert.NotNil(t, err)
+
+	lines, err = New("/tmp/does-not-exist.yaml").(Locator).Locate()
+	assert.Nil(t, err)
+	assert.Len(t, lines, 0)
+}
@@ -0,0 +1,264 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Conflict is a change made on both sides of a merge that can't be combined, where is a day,
+// a setting like configuration/workday or a list like projects
+type Conflict struct {
+	Where   string
+	Message string
+}
+
+// pick is the three-way choice between the sides of a value: the one that changed, or ours when both did.
+// The last return tells if both changed in different ways
+func pick(base interface{}, ours interface{}, theirs interface{}) (interface{}, bool) {
+	if reflect.DeepEqual(ours, theirs) || reflect.DeepEqual(theirs, base) {
+		return ours, false
+	}
+
+	if reflect.DeepEqual(ours, base) {
+		return theirs, false
+	}
+
+	return ours, true
+}
+
+func contains(events []EventItem, event EventItem) bool {
+	for _, current := range events {
+		if reflect.DeepEqual(current, event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func overlap(a EventItem, b EventItem) bool {
+	return a.Start < b.End && b.Start < a.End
+}
+
+// mergeEvents keeps the events of base neither side removed and adds those added on either side,
+// sorted by start. Events added on different sides that overlap are returned as well
+func mergeEvents(base []EventItem, ours []EventItem, theirs []EventItem) ([]EventItem, [][2]EventItem) {
+	var result = make([]EventItem, 0)
+
+	for _, event := range base {
+		if contains(ours, event) && contains(theirs, event) && !contains(result, event) {
+			result = append(result, event)
+		}
+	}
+
+	for _, side := range [][]EventItem{ours, theirs} {
+		for _, event := range side {
+			if !contains(base, event) && !contains(result, event) {
+				result = append(result, event)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start < result[j].Start
+	})
+
+	var overlaps = make([][2]EventItem, 0)
+
+	for i := range result {
+		for j := i + 1; j < len(result); j++ {
+			var a, b = result[i], result[j]
+
+			if !overlap(a, b) {
+				continue
+			}
+
+			if (contains(ours, a) && contains(ours, b)) || (contains(theirs, a) && contains(theirs, b)) {
+				continue
+			}
+
+			overlaps = append(overlaps, [2]EventItem{a, b})
+		}
+	}
+
+	return result, overlaps
+}
+
+// mergeDay merges a day that was changed on both sides, nil days didn't exist on that side
+func mergeDay(day string, base *DayItem, ours *DayItem, theirs *DayItem) (*DayItem, []Conflict) {
+	if ours == nil || theirs == nil {
+		return ours, []Conflict{{Where: day, Message: "removed on one side and changed on the other"}}
+	}
+
+	if base == nil {
+		base = &DayItem{}
+	}
+
+	var conflicts = make([]Conflict, 0)
+
+	if (len(ours.Events) == 0) != (len(theirs.Events) == 0) {
+		var side = "ours"
+		if len(theirs.Events) == 0 {
+			side = "theirs"
+		}
+
+		return ours, []Conflict{{Where: day, Message: fmt.Sprintf("is a day off in %s and has events in the other", side)}}
+	}
+
+	excluded, _ := pick(base.Excluded, ours.Excluded, theirs.Excluded)
+
+	events, overlaps := mergeEvents(base.Events, ours.Events, theirs.Events)
+	breaks, breakOverlaps := mergeEvents(base.Breaks, ours.Breaks, theirs.Breaks)
+
+	for _, pair := range append(overlaps, breakOverlaps...) {
+		conflicts = append(conflicts, Conflict{
+			Where:   day,
+			Message: fmt.Sprintf("%s-%s and %s-%s were added on different sides and overlap", pair[0].Start, pair[0].End, pair[1].Start, pair[1].End),
+		})
+	}
+
+	if len(conflicts) > 0 {
+		return ours, conflicts
+	}
+
+	if len(breaks) == 0 {
+		breaks = nil
+	}
+
+	return &DayItem{Excluded: excluded.(bool), Events: events, Breaks: breaks}, nil
+}
+
+// mergeConfiguration merges the settings one by one
+func mergeConfiguration(base map[string]string, ours map[string]string, theirs map[string]string) (map[string]string, []Conflict) {
+	var result = make(map[string]string)
+
+	var conflicts = make([]Conflict, 0)
+
+	var keys = make(map[string]bool)
+
+	for _, side := range []map[string]string{base, ours, theirs} {
+		for key := range side {
+			keys[key] = true
+		}
+	}
+
+	var sorted = make([]string, 0)
+
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+
+	sort.Strings(sorted)
+
+	var value = func(side map[string]string, key string) *string {
+		if current, ok := side[key]; ok {
+			return &current
+		}
+
+		return nil
+	}
+
+	for _, key := range sorted {
+		chosen, conflict := pick(value(base, key), value(ours, key), value(theirs, key))
+		if conflict {
+			conflicts = append(conflicts, Conflict{Where: "configuration/" + key, Message: "set to different values on both sides"})
+		}
+
+		if chosen := chosen.(*string); chosen != nil {
+			result[key] = *chosen
+		}
+	}
+
+	if len(result) == 0 && ours == nil {
+		result = nil
+	}
+
+	return result, conflicts
+}
+
+// Merge makes the changes from base to theirs to ours. Days are merged event by event, settings one by one
+// and the other lists as a whole, the last invoice number is the highest of both sides. Where both sides
+// changed the same thing in different ways ours is kept and a conflict is returned
+func Merge(base *Document, ours *Document, theirs *Document) (*Document, []Conflict, error) {
+	result, err := clone(ours)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var conflicts = make([]Conflict, 0)
+
+	var configurationConflicts []Conflict
+
+	result.Configuration, configurationConflicts = mergeConfiguration(base.Configuration, ours.Configuration, theirs.Configuration)
+	conflicts = append(conflicts, configurationConflicts...)
+
+	for _, list := range []struct {
+		name               string
+		base, ours, theirs interface{}
+		result             interface{}
+	}{
+		{"overtime", base.Overtime, ours.Overtime, theirs.Overtime, &result.Overtime},
+		{"clients", base.Clients, ours.Clients, theirs.Clients, &result.Clients},
+		{"projects", base.Projects, ours.Projects, theirs.Projects, &result.Projects},
+		{"rates", base.Rates, ours.Rates, theirs.Rates, &result.Rates},
+		{"invoices", base.Invoices, ours.Invoices, theirs.Invoices, &result.Invoices},
+	} {
+		chosen, conflict := pick(list.base, list.ours, list.theirs)
+		if conflict {
+			conflicts = append(conflicts, Conflict{Where: list.name, Message: "changed on both sides"})
+		}
+
+		reflect.ValueOf(list.result).Elem().Set(reflect.ValueOf(chosen))
+	}
+
+	if theirs.LastInvoice > result.LastInvoice {
+		result.LastInvoice = theirs.LastInvoice
+	}
+
+	var baseDays, ourDays, theirDays = flatten(base), flatten(ours), flatten(theirs)
+
+	var seen = make(map[string]bool)
+
+	var days = make([]string, 0)
+
+	for _, side := range []map[string]DayItem{baseDays, ourDays, theirDays} {
+		for day := range side {
+			if !seen[day] {
+				seen[day] = true
+				days = append(days, day)
+			}
+		}
+	}
+
+	sort.Strings(days)
+
+	var item = func(side map[string]DayItem, day string) *DayItem {
+		if current, ok := side[day]; ok {
+			return &current
+		}
+
+		return nil
+	}
+
+	for _, day := range days {
+		var b, o, t = item(baseDays, day), item(ourDays, day), item(theirDays, day)
+
+		chosen, conflict := pick(b, o, t)
+
+		var merged = chosen.(*DayItem)
+
+		if conflict {
+			var dayConflicts []Conflict
+
+			merged, dayConflicts = mergeDay(day, b, o, t)
+			conflicts = append(conflicts, dayConflicts...)
+		}
+
+		setDay(result, day, merged)
+	}
+
+	result.SchemaVersion = CurrentSchemaVersion
+
+	return result, conflicts, nil
+}
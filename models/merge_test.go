@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func document(configuration map[string]string, days map[string][]EventItem) *Document {
+	var d = Document{Configuration: configuration, Items: make(map[string]map[string]DayItem)}
+
+	for day, events := range days {
+		setDay(&d, day, &DayItem{Events: events})
+	}
+
+	return &d
+}
+
+func TestMerge(t *testing.T) {
+	var morning, afternoon, evening = EventItem{Start: "08:00:00", End: "12:00:00"}, EventItem{Start: "12:30:00", End: "16:00:00"}, EventItem{Start: "18:00:00", End: "19:00:00"}
+
+	base := document(map[string]string{"workday": "450", "break": "30"}, map[string][]EventItem{
+		"2026-09-07": {morning},
+		"2026-09-08": {morning},
+		"2026-09-09": {morning},
+	})
+
+	ours := document(map[string]string{"workday": "480", "break": "30"}, map[string][]EventItem{
+		"2026-09-07": {morning, afternoon},
+		"2026-09-08": {morning},
+		"2026-09-10": {morning},
+	})
+
+	theirs := document(map[string]string{"workday": "450", "break": "45"}, map[string][]EventItem{
+		"2026-09-07": {morning, evening},
+		"2026-09-08": {},
+		"2026-09-11": {afternoon},
+	})
+	theirs.Projects = []Project{{Name: "a"}}
+	theirs.LastInvoice = 3
+
+	result, conflicts, err := Merge(base, ours, theirs)
+	assert.Nil(t, err)
+	assert.Len(t, conflicts, 0)
+	assert.Equal(t, map[string]string{"workday": "480", "break": "45"}, result.Configuration)
+	assert.Equal(t, []Project{{Name: "a"}}, result.Projects)
+	assert.Equal(t, 3, result.LastInvoice)
+	assert.Equal(t, []EventItem{morning, afternoon, evening}, result.Items["2026"]["2026-09-07"].Events)
+	assert.Len(t, result.Items["2026"]["2026-09-08"].Events, 0)
+	assert.NotContains(t, result.Items["2026"], "2026-09-09")
+	assert.Contains(t, result.Items["2026"], "2026-09-10")
+	assert.Contains(t, result.Items["2026"], "2026-09-11")
+
+	theirs = document(map[string]string{"workday": "400", "break": "30"}, map[string][]EventItem{
+		"2026-09-07": {morning, {Start: "12:00:00", End: "13:00:00"}},
+		"2026-09-08": {},
+		"2026-09-09": {{Start: "08:00:00", End: "13:00:00"}},
+	})
+	ours.Items["2026"]["2026-09-08"] = DayItem{Events: []EventItem{morning, evening}}
+	ours.Projects = []Project{{Name: "b"}}
+	theirs.Projects = []Project{{Name: "a"}}
+
+	result, conflicts, err = Merge(base, ours, theirs)
+	assert.Nil(t, err)
+
+	var where = make([]string, 0)
+	for _, conflict := range conflicts {
+		where = append(where, conflict.Where)
+	}
+
+	assert.Equal(t, []string{"configuration/workday", "projects", "2026-09-07", "2026-09-08", "2026-09-09"}, where)
+	assert.Equal(t, "480", result.Configuration["workday"])
+	assert.Equal(t, ours.Items["2026"]["2026-09-07"], result.Items["2026"]["2026-09-07"])
+	assert.NotContains(t, result.Items["2026"], "2026-09-09")
+}
@@ -2,7 +2,12 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"git.sr.ht/~hjertnes/timesheet/utils"
@@ -11,24 +16,146 @@ import (
 
 // EventItem keeps track of a event with a start and end
 type EventItem struct {
-	Start string `yaml:"start"`
-	End   string `yaml:"end"`
+	Start    string `yaml:"start"`
+	End      string `yaml:"end"`
+	Project  string `yaml:"project,omitempty"`
+	Note     string `yaml:"note,omitempty"`
+	Billable *bool  `yaml:"billable,omitempty"`
 }
 
 // DayItem keeps track of a day and its ass events
 type DayItem struct {
 	Excluded bool        `yaml:"excluded,flow"`
 	Events   []EventItem `yaml:"events"`
+	Breaks   []EventItem `yaml:"breaks,omitempty"`
+}
+
+// AddBreak logs a break on the day of start
+func (d *Document) AddBreak(start time.Time, end time.Time) error {
+	if !end.After(start) {
+		return errors.New("a break has to end after it starts")
+	}
+
+	year := start.Format("2006")
+	day := start.Format("2006-01-02")
+
+	yearItem, ok := d.Items[year]
+	if !ok {
+		yearItem = make(map[string]DayItem)
+	}
+
+	dayItem, ok := yearItem[day]
+	if !ok {
+		dayItem = DayItem{
+			Excluded: false,
+			Events:   make([]EventItem, 0),
+		}
+	}
+
+	dayItem.Breaks = append(dayItem.Breaks, EventItem{
+		Start: start.Format("15:04:05"),
+		End:   end.Format("15:04:05"),
+	})
+
+	yearItem[day] = dayItem
+	d.Items[year] = yearItem
+
+	return nil
+}
+
+// OvertimeRule describes when worked time is paid with a multiplier.
+// All conditions that are set must hold for a minute to fall into the rule
+type OvertimeRule struct {
+	Name            string   `yaml:"name"`
+	Multiplier      float64  `yaml:"multiplier"`
+	DailyThreshold  int      `yaml:"daily_threshold,omitempty"`
+	WeeklyThreshold int      `yaml:"weekly_threshold,omitempty"`
+	Weekdays        []string `yaml:"weekdays,omitempty"`
+	From            string   `yaml:"from,omitempty"`
+	To              string   `yaml:"to,omitempty"`
+	Excluded        bool     `yaml:"excluded,omitempty"`
+}
+
+// Project groups events, events without a billable flag of their own take it from their project.
+// Budget is in hours, 0 means no budget
+type Project struct {
+	Name     string  `yaml:"name"`
+	Billable bool    `yaml:"billable"`
+	Client   string  `yaml:"client,omitempty"`
+	Budget   float64 `yaml:"budget,omitempty"`
+}
+
+// Set updates a field of the project from a string
+func (p *Project) Set(field string, value string) error {
+	switch field {
+	case "billable":
+		billable, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		p.Billable = billable
+	case "client":
+		p.Client = value
+	case "budget":
+		budget, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+
+		if budget < 0 {
+			return errors.New("budget can't be negative")
+		}
+
+		p.Budget = budget
+	default:
+		return fmt.Errorf("unknown project field %s", field)
+	}
+
+	return nil
+}
+
+// Rate is an hourly rate billed for a project from a date on.
+// A rate with a client and without project is used for the projects of the client, one with neither
+// for projects without a rate of their own
+type Rate struct {
+	Project  string  `yaml:"project,omitempty"`
+	Client   string  `yaml:"client,omitempty"`
+	Amount   float64 `yaml:"amount"`
+	Currency string  `yaml:"currency"`
+	From     string  `yaml:"from,omitempty"`
+}
+
+// Invoice is an invoice that has been generated, kept so its number is never used again
+type Invoice struct {
+	Number   int     `yaml:"number"`
+	Client   string  `yaml:"client"`
+	Month    string  `yaml:"month"`
+	Date     string  `yaml:"date"`
+	Total    float64 `yaml:"total"`
+	Currency string  `yaml:"currency"`
 }
 
 // Document is the root document structure
 type Document struct {
+	SchemaVersion int                           `yaml:"schema_version"`
 	Configuration map[string]string             `yaml:"configuration,omitempty"`
+	Overtime      []OvertimeRule                `yaml:"overtime,omitempty"`
+	Clients       []Client                      `yaml:"clients,omitempty"`
+	Projects      []Project                     `yaml:"projects,omitempty"`
+	Rates         []Rate                        `yaml:"rates,omitempty"`
+	Invoices      []Invoice                     `yaml:"invoices,omitempty"`
+	LastInvoice   int                           `yaml:"last_invoice,omitempty"`
 	Items         map[string]map[string]DayItem `yaml:"items,inline"`
 }
 
 // Add an event
 func (d *Document) Add(start time.Time, end time.Time, excluded bool, off bool) {
+	d.AddEvent(start, end, excluded, off, EventItem{})
+}
+
+// AddEvent adds an event with the details of event, its start and end are taken from start and end
+func (d *Document) AddEvent(start time.Time, end time.Time, excluded bool, off bool, event EventItem) {
 	year := start.Format("2006")
 	day := start.Format("2006-01-02")
 
@@ -49,25 +176,411 @@ func (d *Document) Add(start time.Time, end time.Time, excluded bool, off bool)
 
 	if off {
 		dayItem.Events = make([]EventItem, 0)
+		dayItem.Breaks = nil
 	} else {
-		dayItem.Events = append(dayItem.Events, EventItem{
-			Start: start.Format("15:04:05"),
-			End:   end.Format("15:04:05"),
-		})
+		event.Start = start.Format("15:04:05")
+		event.End = end.Format("15:04:05")
+		dayItem.Events = append(dayItem.Events, event)
 	}
 
 	yearItem[day] = dayItem
 	d.Items[year] = yearItem
 }
 
+// NormalOvertime is the name time that no overtime rule matches is summed under, so no rule can have it
+const NormalOvertime = "normal"
+
+// AddOvertimeRule validates and adds an overtime rule
+func (d *Document) AddOvertimeRule(rule OvertimeRule) error {
+	if rule.Name == "" {
+		return errors.New("overtime rule needs a name")
+	}
+
+	if rule.Name == NormalOvertime {
+		return fmt.Errorf("%s is the time no rule matches and can't be the name of a rule", NormalOvertime)
+	}
+
+	for _, existing := range d.Overtime {
+		if existing.Name == rule.Name {
+			return fmt.Errorf("overtime rule %s already exists", rule.Name)
+		}
+	}
+
+	if rule.Multiplier <= 0 {
+		return errors.New("multiplier must be larger than 0")
+	}
+
+	if rule.DailyThreshold < 0 || rule.WeeklyThreshold < 0 {
+		return errors.New("thresholds can't be negative")
+	}
+
+	if rule.DailyThreshold == 0 && rule.WeeklyThreshold == 0 && len(rule.Weekdays) == 0 &&
+		rule.From == "" && rule.To == "" && !rule.Excluded {
+		return errors.New("overtime rule needs at least one condition")
+	}
+
+	for _, weekday := range rule.Weekdays {
+		if _, err := ParseWeekday(weekday); err != nil {
+			return err
+		}
+	}
+
+	if _, _, err := rule.window(); err != nil {
+		return err
+	}
+
+	d.Overtime = append(d.Overtime, rule)
+
+	return nil
+}
+
+// RemoveOvertimeRule removes the overtime rule with the given name
+func (d *Document) RemoveOvertimeRule(name string) error {
+	for i, rule := range d.Overtime {
+		if rule.Name == name {
+			d.Overtime = append(d.Overtime[:i], d.Overtime[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("overtime rule %s not found", name)
+}
+
+// Matches checks if a worked minute starting at t falls into the rule.
+// daily and weekly are the minutes worked so far including this one
+func (o OvertimeRule) Matches(t time.Time, daily int, weekly int, excluded bool) bool {
+	if o.DailyThreshold > 0 && daily <= o.DailyThreshold {
+		return false
+	}
+
+	if o.WeeklyThreshold > 0 && weekly <= o.WeeklyThreshold {
+		return false
+	}
+
+	if o.Excluded && !excluded {
+		return false
+	}
+
+	if len(o.Weekdays) > 0 {
+		var found = false
+
+		for _, weekday := range o.Weekdays {
+			w, err := ParseWeekday(weekday)
+			if err == nil && w == t.Weekday() {
+				found = true
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if o.From != "" || o.To != "" {
+		from, to, err := o.window()
+		if err != nil {
+			return false
+		}
+
+		var minute = t.Hour()*60 + t.Minute()
+
+		if from <= to {
+			return minute >= from && minute < to
+		}
+
+		return minute >= from || minute < to
+	}
+
+	return true
+}
+
+// window returns the time of day window of the rule in minutes after midnight
+func (o OvertimeRule) window() (int, int, error) {
+	var from = 0
+
+	var to = 24 * 60
+
+	if o.From != "" {
+		t, err := time.Parse("15:04", o.From)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		from = t.Hour()*60 + t.Minute()
+	}
+
+	if o.To != "" {
+		t, err := time.Parse("15:04", o.To)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		to = t.Hour()*60 + t.Minute()
+	}
+
+	return from, to, nil
+}
+
+// ParseWeekday reads a weekday by its english name or the first three letters of it
+func ParseWeekday(name string) (time.Weekday, error) {
+	var n = strings.ToLower(name)
+
+	for w := time.Sunday; w <= time.Saturday; w++ {
+		var full = strings.ToLower(w.String())
+		if n == full || n == full[:3] {
+			return w, nil
+		}
+	}
+
+	return time.Sunday, fmt.Errorf("unknown weekday %s", name)
+}
+
+// Project finds a project by name
+func (d *Document) Project(name string) (*Project, bool) {
+	for i := range d.Projects {
+		if d.Projects[i].Name == name {
+			return &d.Projects[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// AddProject adds a project
+func (d *Document) AddProject(project Project) error {
+	if project.Name == "" {
+		return errors.New("project needs a name")
+	}
+
+	if _, ok := d.Project(project.Name); ok {
+		return fmt.Errorf("project %s already exists", project.Name)
+	}
+
+	if _, ok := d.Client(project.Client); project.Client != "" && !ok {
+		return fmt.Errorf("client %s not found", project.Client)
+	}
+
+	if project.Budget < 0 {
+		return errors.New("budget can't be negative")
+	}
+
+	d.Projects = append(d.Projects, project)
+
+	return nil
+}
+
+// EditProject updates a field of a project
+func (d *Document) EditProject(name string, field string, value string) error {
+	project, ok := d.Project(name)
+	if !ok {
+		return fmt.Errorf("project %s not found", name)
+	}
+
+	if _, ok := d.Client(value); field == "client" && value != "" && !ok {
+		return fmt.Errorf("client %s not found", value)
+	}
+
+	return project.Set(field, value)
+}
+
+// RemoveProject removes a project, events logged on it are kept
+func (d *Document) RemoveProject(name string) error {
+	for i, project := range d.Projects {
+		if project.Name == name {
+			d.Projects = append(d.Projects[:i], d.Projects[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("project %s not found", name)
+}
+
+// Billable tells if an event should be billed, from its own flag, its project or else true
+func (d *Document) Billable(event EventItem) bool {
+	if event.Billable != nil {
+		return *event.Billable
+	}
+
+	if project, ok := d.Project(event.Project); ok {
+		return project.Billable
+	}
+
+	return true
+}
+
+// AddRate validates and adds a rate
+func (d *Document) AddRate(rate Rate) error {
+	if rate.Amount < 0 {
+		return errors.New("amount can't be negative")
+	}
+
+	if rate.Currency == "" {
+		return errors.New("rate needs a currency")
+	}
+
+	if rate.From != "" {
+		if _, err := time.Parse("2006-01-02", rate.From); err != nil {
+			return err
+		}
+	}
+
+	for _, existing := range d.Rates {
+		if existing.Project == rate.Project && existing.Client == rate.Client && existing.From == rate.From {
+			return fmt.Errorf("a rate for %s from %s already exists", describeRate(rate), rate.From)
+		}
+	}
+
+	d.Rates = append(d.Rates, rate)
+
+	return nil
+}
+
+// RemoveRate removes the rate at index
+func (d *Document) RemoveRate(index int) error {
+	if index < 0 || index >= len(d.Rates) {
+		return fmt.Errorf("rate %d not found", index+1)
+	}
+
+	d.Rates = append(d.Rates[:index], d.Rates[index+1:]...)
+
+	return nil
+}
+
+// describeRate names what a rate is for in messages
+func describeRate(rate Rate) string {
+	switch {
+	case rate.Project != "":
+		return "project " + rate.Project
+	case rate.Client != "":
+		return "client " + rate.Client
+	default:
+		return "every project"
+	}
+}
+
+// RateFor finds the rate in effect for a project on a day (yyyy-mm-dd). Rates of the project
+// come first, then the rates of its client, the default rate of the client and last the rates
+// without project or client. The client of a rate is the one the project is linked to, if it is linked to one
+func (d *Document) RateFor(project string, day string) (Rate, bool) {
+	var client = ""
+
+	if p, ok := d.Project(project); ok {
+		client = p.Client
+	}
+
+	result, found := d.latestRate(day, func(rate Rate) bool {
+		return rate.Project == project
+	})
+
+	if !found && client != "" {
+		result, found = d.latestRate(day, func(rate Rate) bool {
+			return rate.Project == "" && rate.Client == client
+		})
+	}
+
+	if c, ok := d.Client(client); !found && ok && c.Rate > 0 {
+		result = Rate{Project: project, Client: client, Amount: c.Rate, Currency: c.Currency}
+		found = true
+	}
+
+	if !found {
+		result, found = d.latestRate(day, func(rate Rate) bool {
+			return rate.Project == "" && rate.Client == ""
+		})
+	}
+
+	if found && client != "" {
+		result.Client = client
+	}
+
+	return result, found
+}
+
+// latestRate finds the rate matching with the latest start on or before day
+func (d *Document) latestRate(day string, match func(rate Rate) bool) (Rate, bool) {
+	var result Rate
+
+	var found = false
+
+	for _, rate := range d.Rates {
+		if !match(rate) || rate.From > day {
+			continue
+		}
+
+		if !found || rate.From > result.From {
+			result = rate
+			found = true
+		}
+	}
+
+	return result, found
+}
+
+// AddInvoice gives the invoice the next free number and keeps it. The last number is kept on its own,
+// so numbers of invoices that were undone aren't used again
+func (d *Document) AddInvoice(invoice Invoice) Invoice {
+	invoice.Number = d.LastInvoice + 1
+
+	for _, existing := range d.Invoices {
+		if existing.Number >= invoice.Number {
+			invoice.Number = existing.Number + 1
+		}
+	}
+
+	d.Invoices = append(d.Invoices, invoice)
+	d.LastInvoice = invoice.Number
+
+	return invoice
+}
+
+// Empty checks if the document has no settings and nothing logged or set up
+func (d *Document) Empty() bool {
+	for _, days := range d.Items {
+		if len(days) > 0 {
+			return false
+		}
+	}
+
+	return len(d.Configuration) == 0 &&
+		len(d.Overtime) == 0 &&
+		len(d.Clients) == 0 &&
+		len(d.Projects) == 0 &&
+		len(d.Rates) == 0 &&
+		len(d.Invoices) == 0 &&
+		d.LastInvoice == 0
+}
+
+// Sort orders the events and breaks of every day by when they start, events starting at the same time keep their order
+func (d *Document) Sort() {
+	for _, days := range d.Items {
+		for _, item := range days {
+			for _, events := range [][]EventItem{item.Events, item.Breaks} {
+				sort.SliceStable(events, func(i, j int) bool {
+					return events[i].Start < events[j].Start
+				})
+			}
+		}
+	}
+}
+
 // Repository is the exposed interface
 type Repository interface {
 	Load() (*Document, error)
 	Save(d *Document) error
 }
 
+// Filer is a repository that is kept in a file
+type Filer interface {
+	File() string
+}
+
 type repository struct {
 	filename string
+	crypt    *crypt
+}
+
+func (r *repository) File() string {
+	return r.filename
 }
 
 func (r *repository) Load() (*Document, error) {
@@ -88,6 +601,16 @@ func (r *repository) Load() (*Document, error) {
 		return nil, err
 	}
 
+	content, err = r.Unseal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err = upgrade(r.filename, content, r.write)
+	if err != nil {
+		return nil, err
+	}
+
 	var document Document
 
 	err = yaml.Unmarshal(content, &document)
@@ -98,25 +621,68 @@ func (r *repository) Load() (*Document, error) {
 	return &document, nil
 }
 
-func (r *repository) Save(d *Document) error {
-	f, err := utils.OpenOrCreate(r.filename)
+// write replaces a file with content, encrypted when the repository is
+func (r *repository) write(filename string, content []byte) error {
+	content, err := r.Seal(content)
 	if err != nil {
 		return err
 	}
 
-	defer f.Close()
+	return ioutil.WriteFile(filename, content, 0600)
+}
+
+// Save replaces the file with the document, a shorter document doesn't leave the end of the old one behind.
+// Years, days and events are written in order, so the file only changes where the document did
+func (r *repository) Save(d *Document) error {
+	d.SchemaVersion = CurrentSchemaVersion
+
+	d.Sort()
 
 	content, err := yaml.Marshal(d)
 	if err != nil {
 		return err
 	}
 
-	_, err = f.Write(content)
-	return err
+	return r.write(r.filename, content)
 }
 
 func New(filename string) Repository {
 	return &repository{
-		filename,
+		filename: filename,
+	}
+}
+
+// NewSQLite is a repository keeping the document in a sqlite database. Saving only writes the days
+// that changed, loading still reads every day
+func NewSQLite(filename string) Repository {
+	return &sqliteRepository{
+		filename: filename,
 	}
 }
+
+// Path returns the file of a storage spec like yaml:<file>
+func Path(spec string) string {
+	var parts = strings.SplitN(spec, ":", 2)
+
+	return parts[len(parts)-1]
+}
+
+// Open opens the storage of a spec like yaml:<file>, encrypted:<file> or sqlite:<file>, a spec without backend is a yaml file.
+// The passphrase of encrypted files is read from the environment
+func Open(spec string) (Repository, error) {
+	var parts = strings.SplitN(spec, ":", 2)
+	if len(parts) == 1 {
+		return New(spec), nil
+	}
+
+	switch parts[0] {
+	case "yaml":
+		return New(parts[1]), nil
+	case "encrypted":
+		return NewEncrypted(parts[1], EnvironmentPassphrase), nil
+	case "sqlite":
+		return NewSQLite(parts[1]), nil
+	}
+
+	return nil, fmt.Errorf("unknown storage backend %s", parts[0])
+}
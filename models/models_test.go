@@ -1,7 +1,10 @@
 package models
 
 import (
+	"io/ioutil"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,3 +32,195 @@ func TestLoadSave(t *testing.T) {
 
 	os.Remove("/tmp/filename")
 }
+
+func TestSaveSorted(t *testing.T) {
+	var filename = "/tmp/timesheet-sorted.yaml"
+
+	defer os.Remove(filename)
+
+	d := Document{Items: make(map[string]map[string]DayItem)}
+
+	for _, day := range []int{9, 7, 8} {
+		d.AddEvent(time.Date(2026, 9, day, 13, 0, 0, 0, time.UTC), time.Date(2026, 9, day, 16, 0, 0, 0, time.UTC), false, false, EventItem{})
+		d.AddEvent(time.Date(2026, 9, day, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, day, 12, 0, 0, 0, time.UTC), false, false, EventItem{})
+	}
+
+	d.AddEvent(time.Date(2025, 12, 31, 8, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC), false, false, EventItem{})
+	assert.Nil(t, d.AddBreak(time.Date(2026, 9, 7, 14, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 14, 15, 0, 0, time.UTC)))
+	assert.Nil(t, d.AddBreak(time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 15, 0, 0, time.UTC)))
+
+	assert.Nil(t, New(filename).Save(&d))
+
+	first, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+
+	loaded, err := New(filename).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, "08:00:00", loaded.Items["2026"]["2026-09-08"].Events[0].Start)
+	assert.Equal(t, "10:00:00", loaded.Items["2026"]["2026-09-07"].Breaks[0].Start)
+
+	assert.Nil(t, New(filename).Save(loaded))
+
+	second, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, string(first), string(second))
+
+	var order = make([]int, 0)
+	for _, key := range []string{"\"2025\":", "\"2026\":", "2026-09-07", "2026-09-08", "2026-09-09"} {
+		order = append(order, strings.Index(string(first), key))
+	}
+
+	assert.NotContains(t, order, -1)
+	assert.True(t, sort.IntsAreSorted(order))
+}
+
+func TestAddOvertimeRule(t *testing.T) {
+	d := Document{}
+
+	assert.Nil(t, d.AddOvertimeRule(OvertimeRule{Name: "daily", Multiplier: 1.5, DailyThreshold: 540}))
+	assert.NotNil(t, d.AddOvertimeRule(OvertimeRule{Name: "daily", Multiplier: 2, DailyThreshold: 600}))
+	assert.NotNil(t, d.AddOvertimeRule(OvertimeRule{Name: "normal", Multiplier: 2, DailyThreshold: 600}))
+	assert.NotNil(t, d.AddOvertimeRule(OvertimeRule{Name: "", Multiplier: 2, DailyThreshold: 600}))
+	assert.NotNil(t, d.AddOvertimeRule(OvertimeRule{Name: "zero", DailyThreshold: 600}))
+	assert.NotNil(t, d.AddOvertimeRule(OvertimeRule{Name: "always", Multiplier: 2}))
+	assert.NotNil(t, d.AddOvertimeRule(OvertimeRule{Name: "day", Multiplier: 2, Weekdays: []string{"funday"}}))
+	assert.NotNil(t, d.AddOvertimeRule(OvertimeRule{Name: "night", Multiplier: 2, From: "25:00"}))
+	assert.Len(t, d.Overtime, 1)
+
+	assert.Nil(t, d.RemoveOvertimeRule("daily"))
+	assert.NotNil(t, d.RemoveOvertimeRule("daily"))
+	assert.Len(t, d.Overtime, 0)
+}
+
+func TestOvertimeRuleMatches(t *testing.T) {
+	var sunday = time.Date(2026, 9, 6, 10, 0, 0, 0, time.UTC)
+
+	var monday = time.Date(2026, 9, 7, 22, 30, 0, 0, time.UTC)
+
+	daily := OvertimeRule{Name: "daily", Multiplier: 1.5, DailyThreshold: 540}
+	assert.False(t, daily.Matches(monday, 540, 540, false))
+	assert.True(t, daily.Matches(monday, 541, 541, false))
+
+	weekly := OvertimeRule{Name: "weekly", Multiplier: 1.5, WeeklyThreshold: 2400}
+	assert.False(t, weekly.Matches(monday, 600, 2400, false))
+	assert.True(t, weekly.Matches(monday, 600, 2401, false))
+
+	weekend := OvertimeRule{Name: "sunday", Multiplier: 2, Weekdays: []string{"Sun"}}
+	assert.True(t, weekend.Matches(sunday, 1, 1, false))
+	assert.False(t, weekend.Matches(monday, 1, 1, false))
+
+	night := OvertimeRule{Name: "night", Multiplier: 1.25, From: "22:00", To: "06:00"}
+	assert.True(t, night.Matches(monday, 1, 1, false))
+	assert.False(t, night.Matches(sunday, 1, 1, false))
+
+	evening := OvertimeRule{Name: "evening", Multiplier: 1.25, From: "18:00"}
+	assert.True(t, evening.Matches(monday, 1, 1, false))
+	assert.False(t, evening.Matches(sunday, 1, 1, false))
+
+	excluded := OvertimeRule{Name: "excluded", Multiplier: 1.5, Excluded: true}
+	assert.True(t, excluded.Matches(monday, 1, 1, true))
+	assert.False(t, excluded.Matches(monday, 1, 1, false))
+}
+
+func TestAddBreak(t *testing.T) {
+	d := Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]DayItem),
+	}
+
+	var start = time.Date(2026, 9, 7, 11, 30, 0, 0, time.UTC)
+
+	var end = time.Date(2026, 9, 7, 12, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, d.AddBreak(start, end))
+	assert.NotNil(t, d.AddBreak(end, start))
+	assert.Equal(t, []EventItem{{Start: "11:30:00", End: "12:00:00"}}, d.Items["2026"]["2026-09-07"].Breaks)
+
+	d.Add(start, start, false, true)
+	assert.Nil(t, d.Items["2026"]["2026-09-07"].Breaks)
+}
+
+func TestRates(t *testing.T) {
+	d := Document{}
+
+	assert.Nil(t, d.AddRate(Rate{Amount: 800, Currency: "NOK"}))
+	assert.Nil(t, d.AddRate(Rate{Project: "a", Amount: 900, Currency: "NOK", From: "2026-01-01"}))
+	assert.Nil(t, d.AddRate(Rate{Project: "a", Amount: 1000, Currency: "NOK", From: "2026-07-01"}))
+	assert.NotNil(t, d.AddRate(Rate{Project: "a", Amount: 1000, Currency: "NOK", From: "2026-07-01"}))
+	assert.NotNil(t, d.AddRate(Rate{Project: "b", Amount: -1, Currency: "NOK"}))
+	assert.NotNil(t, d.AddRate(Rate{Project: "b", Amount: 1}))
+	assert.NotNil(t, d.AddRate(Rate{Project: "b", Amount: 1, Currency: "NOK", From: "July"}))
+
+	rate, ok := d.RateFor("a", "2026-03-01")
+	assert.True(t, ok)
+	assert.Equal(t, 900.0, rate.Amount)
+
+	rate, _ = d.RateFor("a", "2026-07-01")
+	assert.Equal(t, 1000.0, rate.Amount)
+
+	rate, _ = d.RateFor("a", "2025-12-31")
+	assert.Equal(t, 800.0, rate.Amount)
+
+	rate, _ = d.RateFor("b", "2026-03-01")
+	assert.Equal(t, 800.0, rate.Amount)
+
+	assert.Nil(t, d.RemoveRate(0))
+	assert.NotNil(t, d.RemoveRate(5))
+
+	_, ok = d.RateFor("b", "2026-03-01")
+	assert.False(t, ok)
+}
+
+func TestAddInvoice(t *testing.T) {
+	d := Document{}
+
+	assert.Equal(t, 1, d.AddInvoice(Invoice{Client: "acme"}).Number)
+	assert.Equal(t, 2, d.AddInvoice(Invoice{Client: "acme", Number: 1}).Number)
+
+	d.Invoices = d.Invoices[1:]
+	assert.Equal(t, 3, d.AddInvoice(Invoice{Client: "other"}).Number)
+}
+
+func TestProjects(t *testing.T) {
+	d := Document{}
+
+	var yes = true
+
+	assert.Nil(t, d.AddProject(Project{Name: "internal"}))
+	assert.Nil(t, d.AddProject(Project{Name: "a", Billable: true}))
+	assert.NotNil(t, d.AddProject(Project{Name: "a"}))
+	assert.NotNil(t, d.AddProject(Project{}))
+
+	assert.False(t, d.Billable(EventItem{Project: "internal"}))
+	assert.True(t, d.Billable(EventItem{Project: "internal", Billable: &yes}))
+	assert.True(t, d.Billable(EventItem{Project: "a"}))
+	assert.True(t, d.Billable(EventItem{Project: "unknown"}))
+
+	project, ok := d.Project("internal")
+	assert.True(t, ok)
+	assert.Nil(t, project.Set("billable", "true"))
+	assert.NotNil(t, project.Set("billable", "maybe"))
+	assert.NotNil(t, project.Set("colour", "red"))
+	assert.Nil(t, project.Set("budget", "40"))
+	assert.NotNil(t, project.Set("budget", "-1"))
+	assert.NotNil(t, project.Set("budget", "lots"))
+	assert.Equal(t, 40.0, project.Budget)
+	assert.NotNil(t, d.AddProject(Project{Name: "b", Budget: -1}))
+	assert.True(t, d.Billable(EventItem{Project: "internal"}))
+
+	assert.Nil(t, d.RemoveProject("internal"))
+	assert.NotNil(t, d.RemoveProject("internal"))
+	_, ok = d.Project("internal")
+	assert.False(t, ok)
+}
+
+func TestEmpty(t *testing.T) {
+	d := Document{Items: map[string]map[string]DayItem{"2026": {}}}
+	assert.True(t, d.Empty())
+
+	d.Add(time.Now(), time.Now(), false, true)
+	assert.False(t, d.Empty())
+
+	assert.False(t, (&Document{Configuration: map[string]string{"workday": "450"}}).Empty())
+	assert.False(t, (&Document{Projects: []Project{{Name: "a"}}}).Empty())
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CurrentSchemaVersion is the version of the documents written by this version of timesheet
+const CurrentSchemaVersion = 1
+
+// upgrades[i] turns a document of version i into version i+1. They work on the raw yaml,
+// so they can read documents that don't fit the current structs anymore
+var upgrades = []func(raw map[interface{}]interface{}) error{
+	upgradeTimesWithSeconds,
+}
+
+var documentFields = map[string]bool{
+	"schema_version": true,
+	"configuration":  true,
+	"overtime":       true,
+	"clients":        true,
+	"projects":       true,
+	"rates":          true,
+	"invoices":       true,
+}
+
+var shortTime = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+
+// rawEvents calls fn with every event and break of a raw document
+func rawEvents(raw map[interface{}]interface{}, fn func(event map[interface{}]interface{})) {
+	for key, value := range raw {
+		if documentFields[fmt.Sprint(key)] {
+			continue
+		}
+
+		days, ok := value.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, day := range days {
+			item, ok := day.(map[interface{}]interface{})
+			if !ok {
+				continue
+			}
+
+			for _, list := range []interface{}{item["events"], item["breaks"]} {
+				events, ok := list.([]interface{})
+				if !ok {
+					continue
+				}
+
+				for _, event := range events {
+					if event, ok := event.(map[interface{}]interface{}); ok {
+						fn(event)
+					}
+				}
+			}
+		}
+	}
+}
+
+// upgradeTimesWithSeconds pads times written as 15:04 to 15:04:05, which is what times are read as
+func upgradeTimesWithSeconds(raw map[interface{}]interface{}) error {
+	rawEvents(raw, func(event map[interface{}]interface{}) {
+		for _, field := range []string{"start", "end"} {
+			value, ok := event[field].(string)
+			if !ok {
+				continue
+			}
+
+			if match := shortTime.FindStringSubmatch(value); match != nil {
+				if len(match[1]) == 1 {
+					match[1] = "0" + match[1]
+				}
+
+				event[field] = match[1] + ":" + match[2] + ":00"
+			}
+		}
+	})
+
+	return nil
+}
+
+// upgrade runs the upgrades a document needs to get to the current version. The document as it was
+// is kept next to it as <file>.v<version>.bak first, written with write
+func upgrade(filename string, content []byte, write func(filename string, content []byte) error) ([]byte, error) {
+	var raw map[interface{}]interface{}
+
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	if raw == nil {
+		return content, nil
+	}
+
+	var version = 0
+
+	if value, ok := raw["schema_version"]; ok {
+		number, ok := value.(int)
+		if !ok {
+			return nil, fmt.Errorf("schema_version %v is not a number", value)
+		}
+
+		version = number
+	}
+
+	if version > CurrentSchemaVersion {
+		return nil, fmt.Errorf("%s is of schema version %d, which is newer than this version of timesheet supports", filename, version)
+	}
+
+	if version == CurrentSchemaVersion {
+		return content, nil
+	}
+
+	if err := write(fmt.Sprintf("%s.v%d.bak", filename, version), content); err != nil {
+		return nil, err
+	}
+
+	for _, fn := range upgrades[version:] {
+		if err := fn(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	raw["schema_version"] = CurrentSchemaVersion
+
+	return yaml.Marshal(raw)
+}
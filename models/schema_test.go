@@ -0,0 +1,60 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgrade(t *testing.T) {
+	var filename = "/tmp/timesheet-schema.yaml"
+
+	var old = `configuration:
+  workday: "450"
+"2019":
+  "2019-03-04":
+    excluded: false
+    events:
+    - start: "8:00"
+      end: "12:30"
+    - start: "13:00:00"
+      end: "16:00:00"
+    breaks:
+    - start: "10:00"
+      end: "10:15"
+`
+
+	assert.Nil(t, ioutil.WriteFile(filename, []byte(old), 0600))
+
+	defer os.Remove(filename)
+	defer os.Remove(filename + ".v0.bak")
+
+	d, err := New(filename).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, CurrentSchemaVersion, d.SchemaVersion)
+	assert.Equal(t, "450", d.Configuration["workday"])
+	assert.Equal(t, []EventItem{{Start: "08:00:00", End: "12:30:00"}, {Start: "13:00:00", End: "16:00:00"}}, d.Items["2019"]["2019-03-04"].Events)
+	assert.Equal(t, []EventItem{{Start: "10:00:00", End: "10:15:00"}}, d.Items["2019"]["2019-03-04"].Breaks)
+
+	backup, err := ioutil.ReadFile(filename + ".v0.bak")
+	assert.Nil(t, err)
+	assert.Equal(t, old, string(backup))
+
+	var write = (&repository{filename: filename}).write
+
+	content, err := upgrade(filename, []byte("schema_version: 1\n\"2019\": {}\n"), write)
+	assert.Nil(t, err)
+	assert.Equal(t, "schema_version: 1\n\"2019\": {}\n", string(content))
+
+	content, err = upgrade(filename, []byte(""), write)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(content))
+
+	_, err = upgrade(filename, []byte("schema_version: 2\n"), write)
+	assert.NotNil(t, err)
+
+	_, err = upgrade(filename, []byte("schema_version: two\n"), write)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,498 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	// registers the sqlite3 driver
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+// the rows of the sqlite backend, a day and its events are rows of their own
+// so only the days that changed are written on save
+
+type sqlSetting struct {
+	Key   string `gorm:"primary_key"`
+	Value string
+}
+
+func (sqlSetting) TableName() string {
+	return "settings"
+}
+
+type sqlDay struct {
+	Day      string `gorm:"primary_key"`
+	Year     string `gorm:"index"`
+	Excluded bool
+}
+
+func (sqlDay) TableName() string {
+	return "days"
+}
+
+type sqlEvent struct {
+	ID       uint   `gorm:"primary_key"`
+	Day      string `gorm:"index"`
+	Break    bool
+	Position int
+	Start    string
+	End      string
+	Project  string `gorm:"index"`
+	Note     string
+	Billable *bool
+}
+
+func (sqlEvent) TableName() string {
+	return "events"
+}
+
+type sqlOvertimeRule struct {
+	ID              uint `gorm:"primary_key"`
+	Name            string
+	Multiplier      float64
+	DailyThreshold  int
+	WeeklyThreshold int
+	Weekdays        string
+	From            string
+	To              string
+	Excluded        bool
+}
+
+func (sqlOvertimeRule) TableName() string {
+	return "overtime_rules"
+}
+
+type sqlClient struct {
+	ID uint `gorm:"primary_key"`
+	Client
+}
+
+func (sqlClient) TableName() string {
+	return "clients"
+}
+
+type sqlProject struct {
+	ID uint `gorm:"primary_key"`
+	Project
+}
+
+func (sqlProject) TableName() string {
+	return "projects"
+}
+
+type sqlRate struct {
+	ID uint `gorm:"primary_key"`
+	Rate
+}
+
+func (sqlRate) TableName() string {
+	return "rates"
+}
+
+type sqlInvoice struct {
+	ID uint `gorm:"primary_key"`
+	Invoice
+}
+
+func (sqlInvoice) TableName() string {
+	return "invoices"
+}
+
+// sqlCounter is a number that isn't part of a list, like the last invoice number
+type sqlCounter struct {
+	Key   string `gorm:"primary_key"`
+	Value int
+}
+
+func (sqlCounter) TableName() string {
+	return "counters"
+}
+
+const lastInvoiceCounter = "last_invoice"
+
+var sqlTables = []interface{}{
+	&sqlSetting{},
+	&sqlDay{},
+	&sqlEvent{},
+	&sqlOvertimeRule{},
+	&sqlClient{},
+	&sqlProject{},
+	&sqlRate{},
+	&sqlInvoice{},
+	&sqlCounter{},
+}
+
+type sqliteRepository struct {
+	filename string
+	loaded   map[string]DayItem
+}
+
+func (r *sqliteRepository) File() string {
+	return r.filename
+}
+
+func (r *sqliteRepository) open() (*gorm.DB, error) {
+	db, err := gorm.Open("sqlite3", r.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(sqlTables...).Error; err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// copyDay copies a day and its events, a day without breaks has nil breaks
+func copyDay(item DayItem) DayItem {
+	var events = make([]EventItem, len(item.Events))
+	copy(events, item.Events)
+
+	var breaks []EventItem
+	if len(item.Breaks) > 0 {
+		breaks = make([]EventItem, len(item.Breaks))
+		copy(breaks, item.Breaks)
+	}
+
+	return DayItem{Excluded: item.Excluded, Events: events, Breaks: breaks}
+}
+
+// flatten returns the days of a document by date, with copies of their events
+func flatten(d *Document) map[string]DayItem {
+	var result = make(map[string]DayItem)
+
+	for _, days := range d.Items {
+		for day, item := range days {
+			result[day] = copyDay(item)
+		}
+	}
+
+	return result
+}
+
+// Load reads the whole database into a document. Commands still need all of it, loading only the days
+// a command uses is not done yet
+func (r *sqliteRepository) Load() (*Document, error) {
+	db, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	var document = Document{
+		SchemaVersion: CurrentSchemaVersion,
+		Items:         make(map[string]map[string]DayItem),
+	}
+
+	var settings []sqlSetting
+	if err := db.Find(&settings).Error; err != nil {
+		return nil, err
+	}
+
+	if len(settings) > 0 {
+		document.Configuration = make(map[string]string)
+	}
+
+	for _, setting := range settings {
+		document.Configuration[setting.Key] = setting.Value
+	}
+
+	var rules []sqlOvertimeRule
+	if err := db.Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		var weekdays []string
+		if rule.Weekdays != "" {
+			weekdays = strings.Split(rule.Weekdays, ",")
+		}
+
+		document.Overtime = append(document.Overtime, OvertimeRule{
+			Name:            rule.Name,
+			Multiplier:      rule.Multiplier,
+			DailyThreshold:  rule.DailyThreshold,
+			WeeklyThreshold: rule.WeeklyThreshold,
+			Weekdays:        weekdays,
+			From:            rule.From,
+			To:              rule.To,
+			Excluded:        rule.Excluded,
+		})
+	}
+
+	var clients []sqlClient
+	if err := db.Order("id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+
+	for _, client := range clients {
+		document.Clients = append(document.Clients, client.Client)
+	}
+
+	var projects []sqlProject
+	if err := db.Order("id").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+
+	for _, project := range projects {
+		document.Projects = append(document.Projects, project.Project)
+	}
+
+	var rates []sqlRate
+	if err := db.Order("id").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+
+	for _, rate := range rates {
+		document.Rates = append(document.Rates, rate.Rate)
+	}
+
+	var invoices []sqlInvoice
+	if err := db.Order("id").Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+
+	for _, invoice := range invoices {
+		document.Invoices = append(document.Invoices, invoice.Invoice)
+	}
+
+	var counters []sqlCounter
+	if err := db.Find(&counters).Error; err != nil {
+		return nil, err
+	}
+
+	for _, counter := range counters {
+		if counter.Key == lastInvoiceCounter {
+			document.LastInvoice = counter.Value
+		}
+	}
+
+	var days []sqlDay
+	if err := db.Find(&days).Error; err != nil {
+		return nil, err
+	}
+
+	for _, day := range days {
+		if _, ok := document.Items[day.Year]; !ok {
+			document.Items[day.Year] = make(map[string]DayItem)
+		}
+
+		document.Items[day.Year][day.Day] = DayItem{Excluded: day.Excluded, Events: make([]EventItem, 0)}
+	}
+
+	var events []sqlEvent
+	if err := db.Order("day, position").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		var item = document.Items[event.Day[:4]][event.Day]
+
+		var value = EventItem{
+			Start:    event.Start,
+			End:      event.End,
+			Project:  event.Project,
+			Note:     event.Note,
+			Billable: event.Billable,
+		}
+
+		if event.Break {
+			item.Breaks = append(item.Breaks, value)
+		} else {
+			item.Events = append(item.Events, value)
+		}
+
+		document.Items[event.Day[:4]][event.Day] = item
+	}
+
+	r.loaded = flatten(&document)
+
+	return &document, nil
+}
+
+// replace swaps every row of a table for rows
+func replace(tx *gorm.DB, table interface{}, rows []interface{}) error {
+	if err := tx.Delete(table).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err := tx.Create(row).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveDay writes a day and its events, or removes it when item is nil
+func saveDay(tx *gorm.DB, day string, item *DayItem) error {
+	if err := tx.Where("day = ?", day).Delete(&sqlEvent{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("day = ?", day).Delete(&sqlDay{}).Error; err != nil {
+		return err
+	}
+
+	if item == nil {
+		return nil
+	}
+
+	if err := tx.Create(&sqlDay{Day: day, Year: day[:4], Excluded: item.Excluded}).Error; err != nil {
+		return err
+	}
+
+	for _, list := range []struct {
+		events  []EventItem
+		isBreak bool
+	}{{item.Events, false}, {item.Breaks, true}} {
+		for i, event := range list.events {
+			var row = sqlEvent{
+				Day:      day,
+				Break:    list.isBreak,
+				Position: i,
+				Start:    event.Start,
+				End:      event.End,
+				Project:  event.Project,
+				Note:     event.Note,
+				Billable: event.Billable,
+			}
+
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Save writes the document in one transaction. Days that are the same as when they were loaded are left alone.
+// The tables are kept up to date by gorm, so the document is always of the current schema version
+func (r *sqliteRepository) Save(d *Document) error {
+	d.SchemaVersion = CurrentSchemaVersion
+
+	d.Sort()
+
+	db, err := r.open()
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	var tx = db.Begin()
+
+	if err := r.save(tx, d); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	r.loaded = flatten(d)
+
+	return nil
+}
+
+func (r *sqliteRepository) save(tx *gorm.DB, d *Document) error {
+	var settings = make([]interface{}, 0)
+	for key, value := range d.Configuration {
+		settings = append(settings, &sqlSetting{Key: key, Value: value})
+	}
+
+	var rules = make([]interface{}, 0)
+	for _, rule := range d.Overtime {
+		rules = append(rules, &sqlOvertimeRule{
+			Name:            rule.Name,
+			Multiplier:      rule.Multiplier,
+			DailyThreshold:  rule.DailyThreshold,
+			WeeklyThreshold: rule.WeeklyThreshold,
+			Weekdays:        strings.Join(rule.Weekdays, ","),
+			From:            rule.From,
+			To:              rule.To,
+			Excluded:        rule.Excluded,
+		})
+	}
+
+	var clients = make([]interface{}, 0)
+	for _, client := range d.Clients {
+		clients = append(clients, &sqlClient{Client: client})
+	}
+
+	var projects = make([]interface{}, 0)
+	for _, project := range d.Projects {
+		projects = append(projects, &sqlProject{Project: project})
+	}
+
+	var rates = make([]interface{}, 0)
+	for _, rate := range d.Rates {
+		rates = append(rates, &sqlRate{Rate: rate})
+	}
+
+	var invoices = make([]interface{}, 0)
+	for _, invoice := range d.Invoices {
+		invoices = append(invoices, &sqlInvoice{Invoice: invoice})
+	}
+
+	var counters = make([]interface{}, 0)
+	if d.LastInvoice > 0 {
+		counters = append(counters, &sqlCounter{Key: lastInvoiceCounter, Value: d.LastInvoice})
+	}
+
+	for _, table := range []struct {
+		table interface{}
+		rows  []interface{}
+	}{
+		{&sqlSetting{}, settings},
+		{&sqlOvertimeRule{}, rules},
+		{&sqlClient{}, clients},
+		{&sqlProject{}, projects},
+		{&sqlRate{}, rates},
+		{&sqlInvoice{}, invoices},
+		{&sqlCounter{}, counters},
+	} {
+		if err := replace(tx, table.table, table.rows); err != nil {
+			return err
+		}
+	}
+
+	var current = flatten(d)
+
+	if r.loaded == nil {
+		for _, table := range []interface{}{&sqlEvent{}, &sqlDay{}} {
+			if err := tx.Delete(table).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for day := range r.loaded {
+		if _, ok := current[day]; !ok {
+			if err := saveDay(tx, day, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	for day, item := range current {
+		var item = item
+
+		if previous, ok := r.loaded[day]; ok && reflect.DeepEqual(previous, item) {
+			continue
+		}
+
+		if err := saveDay(tx, day, &item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpen(t *testing.T) {
+	r, err := Open("/tmp/timesheet.yaml")
+	assert.Nil(t, err)
+	assert.IsType(t, &repository{}, r)
+
+	r, err = Open("yaml:/tmp/timesheet.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, &repository{filename: "/tmp/timesheet.yaml"}, r)
+
+	r, err = Open("sqlite:/tmp/timesheet.db")
+	assert.Nil(t, err)
+	assert.Equal(t, &sqliteRepository{filename: "/tmp/timesheet.db"}, r)
+
+	_, err = Open("postgres://localhost/timesheet")
+	assert.NotNil(t, err)
+}
+
+func TestSQLite(t *testing.T) {
+	var filename = "/tmp/timesheet-test.db"
+
+	os.Remove(filename)
+
+	defer os.Remove(filename)
+
+	var billable = false
+
+	d := Document{
+		Configuration: map[string]string{"workday": "450", "break": "30"},
+		Items:         make(map[string]map[string]DayItem),
+	}
+
+	assert.Nil(t, d.AddOvertimeRule(OvertimeRule{Name: "weekend", Multiplier: 2, Weekdays: []string{"sat", "sun"}}))
+	assert.Nil(t, d.AddClient(Client{Name: "acme", VAT: "NO123", Rate: 1000, Currency: "NOK"}))
+	assert.Nil(t, d.AddProject(Project{Name: "a", Billable: true, Client: "acme", Budget: 10}))
+	assert.Nil(t, d.AddRate(Rate{Project: "a", Amount: 1200, Currency: "NOK", From: "2026-01-01"}))
+	d.AddInvoice(Invoice{Client: "acme", Month: "2026-08", Date: "2026-09-01", Total: 100, Currency: "NOK"})
+
+	d.AddEvent(time.Date(2026, 9, 7, 13, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), false, false, EventItem{Project: "a", Billable: &billable})
+	d.AddEvent(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 12, 0, 0, 0, time.UTC), false, false, EventItem{Project: "a", Note: "design"})
+	assert.Nil(t, d.AddBreak(time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 30, 0, 0, time.UTC)))
+	d.AddEvent(time.Date(2026, 9, 8, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 0, 0, 0, 0, time.UTC), true, true, EventItem{})
+
+	var r = NewSQLite(filename)
+	assert.Nil(t, r.Save(&d))
+
+	loaded, err := NewSQLite(filename).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, &d, loaded)
+
+	r = NewSQLite(filename)
+	changed, err := r.Load()
+	assert.Nil(t, err)
+
+	delete(changed.Items["2026"], "2026-09-08")
+	changed.AddEvent(time.Date(2026, 9, 9, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 9, 9, 0, 0, 0, time.UTC), false, false, EventItem{})
+	changed.Configuration["break"] = "45"
+	assert.Nil(t, changed.RemoveRate(0))
+	assert.Nil(t, r.Save(changed))
+
+	loaded, err = NewSQLite(filename).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, changed.Items, loaded.Items)
+	assert.Len(t, loaded.Rates, 0)
+	assert.Equal(t, "45", loaded.Configuration["break"])
+
+	_, err = NewSQLite("/tmp/does-not-exist/timesheet.db").Load()
+	assert.NotNil(t, err)
+}
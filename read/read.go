@@ -3,6 +3,7 @@ package read
 
 import (
 	"bufio"
+	"io"
 	"os"
 
 	"git.sr.ht/~hjertnes/timesheet/utils"
@@ -13,18 +14,32 @@ type Read interface {
 	Execute(f *os.File) string
 }
 
-type read struct{}
+type read struct {
+	readers map[*os.File]*bufio.Reader
+}
 
 // New constructor
 func New() Read {
 	return &read{}
 }
 
-//Execute read os.File
-func (r read) Execute(f *os.File) string {
-	var reader = bufio.NewReader(f)
+//Execute read a line from os.File, with its newline. At the end of the file it returns what is left without one,
+//so an empty string means there is nothing more to read
+func (r *read) Execute(f *os.File) string {
+	if r.readers == nil {
+		r.readers = make(map[*os.File]*bufio.Reader)
+	}
+
+	reader, ok := r.readers[f]
+	if !ok {
+		reader = bufio.NewReader(f)
+		r.readers[f] = reader
+	}
 
 	var line, err = reader.ReadString('\n')
+	if err == io.EOF {
+		return line
+	}
 
 	utils.ErrorHandler(err)
 
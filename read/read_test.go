@@ -25,3 +25,31 @@ func Test(t *testing.T) {
 
 	_ = os.Remove("./test")
 }
+
+func TestEOF(t *testing.T) {
+	var r = New()
+
+	var f, _ = os.Create("./test")
+
+	var _, err = f.WriteString("first\nsecond")
+
+	utils.ErrorHandler(err)
+
+	_ = f.Close()
+
+	f, _ = os.Open("./test")
+
+	defer os.Remove("./test")
+
+	if line := r.Execute(f); line != "first\n" {
+		t.Errorf("expected first line, got %q", line)
+	}
+
+	if line := r.Execute(f); line != "second" {
+		t.Errorf("expected the rest without newline, got %q", line)
+	}
+
+	if line := r.Execute(f); line != "" {
+		t.Errorf("expected nothing at the end, got %q", line)
+	}
+}
@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+const (
+	breakModeFixed = "fixed"
+	breakModeGaps  = "gaps"
+)
+
+// breakRule decides how much break is deducted from a day. It is read from the settings
+// break, break_mode, break_after and break_days_off
+type breakRule struct {
+	minutes int
+	mode    string
+	after   int
+	daysOff bool
+}
+
+func (r *runner) breakRule() breakRule {
+	var _, breaktime = r.getSettings()
+
+	after, err := strconv.Atoi(r.setting("break_after"))
+	utils.ErrorHandler(err)
+
+	daysOff, err := strconv.ParseBool(r.setting("break_days_off"))
+	utils.ErrorHandler(err)
+
+	var mode = r.setting("break_mode")
+	if mode != breakModeFixed && mode != breakModeGaps {
+		utils.ErrorHandler(fmt.Errorf("unknown break_mode %s", mode))
+	}
+
+	return breakRule{
+		minutes: breaktime,
+		mode:    mode,
+		after:   after,
+		daysOff: daysOff,
+	}
+}
+
+// deduction returns the minutes to deduct from a day with worked minutes and gaps
+// minutes between its events. Days off are days without any events
+func (b breakRule) deduction(worked int, gaps int, excluded bool, off bool) int {
+	if excluded {
+		return 0
+	}
+
+	if off {
+		if b.daysOff {
+			return b.minutes
+		}
+
+		return 0
+	}
+
+	if worked <= b.after {
+		return 0
+	}
+
+	var result = b.minutes
+
+	if b.mode == breakModeGaps {
+		result -= gaps
+	}
+
+	if result < 0 {
+		return 0
+	}
+
+	if result > worked {
+		return worked
+	}
+
+	return result
+}
+
+// segment is a worked interval belonging to one of the events of a day
+type segment struct {
+	start time.Time
+	end   time.Time
+	index int
+	event models.EventItem
+}
+
+func (s segment) minutes() int {
+	return int(s.end.Sub(s.start).Minutes())
+}
+
+// segments returns the worked intervals of a day, with logged breaks cut out of its events
+func segments(entry dayEntry) []segment {
+	var result = make([]segment, 0)
+
+	for i, item := range entry.item.Events {
+		s, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.Start)
+		utils.ErrorHandler(err)
+		e, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.End)
+		utils.ErrorHandler(err)
+
+		result = append(result, segment{start: s, end: e, index: i, event: item})
+	}
+
+	for _, item := range entry.item.Breaks {
+		s, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.Start)
+		utils.ErrorHandler(err)
+		e, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.End)
+		utils.ErrorHandler(err)
+
+		var cut = make([]segment, 0)
+
+		for _, current := range result {
+			if !e.After(current.start) || !s.Before(current.end) {
+				cut = append(cut, current)
+				continue
+			}
+
+			if current.start.Before(s) {
+				cut = append(cut, segment{start: current.start, end: s, index: current.index, event: current.event})
+			}
+
+			if e.Before(current.end) {
+				cut = append(cut, segment{start: e, end: current.end, index: current.index, event: current.event})
+			}
+		}
+
+		result = cut
+	}
+
+	return result
+}
+
+// worked returns the minutes logged on a day before any configured break is deducted,
+// and the minutes of gaps between its events
+func worked(entry dayEntry) (int, int) {
+	var total = 0
+
+	var gaps = 0
+
+	for _, current := range segments(entry) {
+		total += current.minutes()
+	}
+
+	var previous = ""
+
+	for _, item := range entry.item.Events {
+		if previous != "" && item.Start > previous {
+			s, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.Start)
+			utils.ErrorHandler(err)
+			p, err := utils.TimeFromDateStringAndTimeString2(entry.day, previous)
+			utils.ErrorHandler(err)
+
+			gaps += int(s.Sub(p).Minutes())
+		}
+
+		if item.End > previous {
+			previous = item.End
+		}
+	}
+
+	return total, gaps
+}
+
+// workedMinutes returns the minutes worked on a day and the break deducted from them.
+// Days with logged breaks use those, other days fall back to the configured break.
+// Every summary goes through this so the break rules are applied the same way everywhere
+func (r *runner) workedMinutes(rule breakRule, entry dayEntry) (int, int) {
+	var total, gaps = worked(entry)
+
+	if len(entry.item.Breaks) > 0 {
+		return total, 0
+	}
+
+	return total, rule.deduction(total, gaps, entry.item.Excluded, len(entry.item.Events) == 0)
+}
+
+// netSegments returns the worked segments of a day with the break deduction
+// taken from the earliest worked minutes
+func (r *runner) netSegments(rule breakRule, entry dayEntry) []segment {
+	var _, skip = r.workedMinutes(rule, entry)
+
+	var result = make([]segment, 0)
+
+	for _, current := range segments(entry) {
+		if skip >= current.minutes() {
+			skip -= current.minutes()
+			continue
+		}
+
+		current.start = current.start.Add(time.Duration(skip) * time.Minute)
+		skip = 0
+
+		result = append(result, current)
+	}
+
+	return result
+}
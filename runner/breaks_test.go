@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakRuleDeduction(t *testing.T) {
+	fixed := breakRule{minutes: 30, mode: breakModeFixed, daysOff: true}
+	assert.Equal(t, 30, fixed.deduction(480, 0, false, false))
+	assert.Equal(t, 0, fixed.deduction(480, 0, true, false))
+	assert.Equal(t, 30, fixed.deduction(0, 0, false, true))
+	assert.Equal(t, 10, fixed.deduction(10, 0, false, false))
+
+	threshold := breakRule{minutes: 30, mode: breakModeFixed, after: 330}
+	assert.Equal(t, 0, threshold.deduction(120, 0, false, false))
+	assert.Equal(t, 0, threshold.deduction(330, 0, false, false))
+	assert.Equal(t, 30, threshold.deduction(331, 0, false, false))
+	assert.Equal(t, 0, threshold.deduction(0, 0, false, true))
+
+	gaps := breakRule{minutes: 30, mode: breakModeGaps}
+	assert.Equal(t, 10, gaps.deduction(480, 20, false, false))
+	assert.Equal(t, 0, gaps.deduction(480, 45, false, false))
+}
+
+func TestWorkedMinutes(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	d.Configuration["workday"] = "450"
+	d.Configuration["break"] = "30"
+
+	r.Add(time.Date(2026, 9, 7, 12, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), false, models.EventItem{})
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 11, 45, 0, 0, time.UTC), false, models.EventItem{})
+	r.Off(time.Date(2026, 9, 8, 0, 0, 0, 0, time.UTC))
+
+	days := r.days()
+	assert.Len(t, days, 2)
+
+	minutes, gaps := worked(days[0])
+	assert.Equal(t, 465, minutes)
+	assert.Equal(t, 15, gaps)
+
+	minutes, deduction := r.workedMinutes(r.breakRule(), days[0])
+	assert.Equal(t, 465, minutes)
+	assert.Equal(t, 30, deduction)
+
+	_, deduction = r.workedMinutes(r.breakRule(), days[1])
+	assert.Equal(t, 30, deduction)
+
+	d.Configuration["break_mode"] = "gaps"
+	d.Configuration["break_days_off"] = "false"
+
+	_, deduction = r.workedMinutes(r.breakRule(), days[0])
+	assert.Equal(t, 15, deduction)
+
+	_, deduction = r.workedMinutes(r.breakRule(), days[1])
+	assert.Equal(t, 0, deduction)
+
+	r.SummaryYear()
+	r.SummaryDay(ReportOptions{})
+
+	d.Configuration["break_mode"] = "lunch"
+	assert.Panics(t, func() { r.breakRule() })
+}
+
+func TestLoggedBreaks(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	d.Configuration["workday"] = "450"
+	d.Configuration["break"] = "30"
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), false, models.EventItem{})
+	r.Break(time.Date(2026, 9, 7, 11, 30, 0, 0, time.UTC), time.Date(2026, 9, 7, 12, 15, 0, 0, time.UTC))
+	r.Break(time.Date(2026, 9, 7, 15, 45, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 30, 0, 0, time.UTC))
+
+	days := r.days()
+	assert.Len(t, segments(days[0]), 2)
+
+	minutes, deduction := r.workedMinutes(r.breakRule(), days[0])
+	assert.Equal(t, 420, minutes)
+	assert.Equal(t, 0, deduction)
+
+	assert.Equal(t, 420, r.overtime()["2026-09"][normalBucket])
+	assert.Panics(t, func() {
+		r.Break(time.Date(2026, 9, 7, 12, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 11, 0, 0, 0, time.UTC))
+	})
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"os"
+	"strconv"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// ClientAdd adds a client
+func (r *runner) ClientAdd(client models.Client) {
+	utils.ErrorHandler(r.document.AddClient(client))
+}
+
+// ClientList prints a table of clients
+func (r *runner) ClientList() {
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"Name", "Address", "VAT", "Rate", "Currency", "Rounding"})
+
+	for _, client := range r.document.Clients {
+		var rounding = ""
+		if client.Rounding != "" || client.RoundingMinutes != 0 || client.RoundingScope != "" {
+			rounding = r.roundingFor(client.Name).String()
+		}
+
+		table.Append([]string{
+			client.Name,
+			client.Address,
+			client.VAT,
+			strconv.FormatFloat(client.Rate, 'f', 2, 64),
+			client.Currency,
+			rounding,
+		})
+	}
+
+	table.Render()
+}
+
+// ClientEdit updates a field of a client
+func (r *runner) ClientEdit(name string, field string, value string) {
+	utils.ErrorHandler(r.document.EditClient(name, field, value))
+}
+
+// ClientRemove removes a client
+func (r *runner) ClientRemove(name string) {
+	utils.ErrorHandler(r.document.RemoveClient(name))
+}
+
+// byClient splits segments of a day by the client they are billed to
+func (r *runner) byClient(net []segment, day string) map[string][]segment {
+	var result = make(map[string][]segment)
+
+	for _, current := range net {
+		var client = r.document.ClientFor(current.event, day)
+		result[client] = append(result[client], current)
+	}
+
+	return result
+}
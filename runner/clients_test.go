@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClients(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	d.Configuration["workday"] = "60"
+	d.Configuration["break"] = "0"
+
+	r.ClientAdd(models.Client{Name: "acme", Rate: 1000, Currency: "NOK", Rounding: "up"})
+	r.ClientAdd(models.Client{Name: "other", Rate: 500, Currency: "NOK"})
+	r.ProjectAdd(models.Project{Name: "a", Billable: true, Client: "acme"})
+	r.ProjectAdd(models.Project{Name: "b", Billable: true, Client: "other"})
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 8, 50, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	r.Add(time.Date(2026, 9, 7, 9, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 9, 50, 0, 0, time.UTC), false, models.EventItem{Project: "b"})
+
+	var clients = r.byClient(r.netSegments(r.breakRule(), r.days()[0]), "2026-09-07")
+	assert.Len(t, clients["acme"], 1)
+	assert.Len(t, clients["other"], 1)
+
+	assert.Equal(t, "rounded up to 15m per day", r.roundingFor("acme").String())
+	assert.Equal(t, "not rounded", r.roundingFor("other").String())
+
+	earnings := r.earnings()
+	assert.Len(t, earnings, 2)
+	assert.Equal(t, "acme", earnings[0].client)
+	assert.Equal(t, 60, earnings[0].minutes)
+	assert.Equal(t, "other", earnings[1].client)
+	assert.Equal(t, 50, earnings[1].minutes)
+
+	lines, _, err := r.invoiceLines("acme", "2026-09", invoicePerDay)
+	assert.Nil(t, err)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, 60, lines[0].Minutes)
+
+	r.SummaryDay(ReportOptions{Client: "acme"})
+	r.SummaryDay(ReportOptions{ByClient: true})
+	r.ClientEdit("acme", "address", "Street 1\nOslo")
+	r.ClientList()
+	r.Invoice(InvoiceOptions{Client: "acme", Month: "2026-09", Per: invoicePerDay, Format: invoiceText})
+
+	assert.Panics(t, func() { r.ClientEdit("acme", "rounding", "sideways") })
+	assert.NotPanics(t, func() { r.roundingFor("acme") })
+
+	client, _ := d.Client("acme")
+	client.Rounding = "sideways"
+	assert.Panics(t, func() { r.roundingFor("acme") })
+	assert.Panics(t, func() { r.ClientRemove("acme") })
+	r.ProjectEdit("a", "client", "")
+	r.ClientRemove("acme")
+	assert.Panics(t, func() { r.ClientAdd(models.Client{Name: "other"}) })
+}
@@ -0,0 +1,336 @@
+package runner
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// CSVOptions maps the columns of a csv file to events. Columns left empty are taken from the preset
+type CSVOptions struct {
+	Preset     string
+	Date       string
+	Start      string
+	End        string
+	Duration   string
+	Project    string
+	Note       string
+	DateFormat string
+	Delimiter  string
+	DryRun     bool
+}
+
+var csvPresets = map[string]CSVOptions{
+	"toggl": {
+		Date:       "Start date",
+		Start:      "Start time",
+		End:        "End time",
+		Duration:   "Duration",
+		Project:    "Project",
+		Note:       "Description",
+		DateFormat: "2006-01-02",
+	},
+	"clockify": {
+		Date:       "Start Date",
+		Start:      "Start Time",
+		End:        "End Time",
+		Duration:   "Duration (h)",
+		Project:    "Project",
+		Note:       "Description",
+		DateFormat: "01/02/2006",
+	},
+}
+
+var csvTimeFormats = []string{"15:04:05", "15:04", "03:04:05 PM", "3:04:05 PM", "03:04 PM", "3:04 PM"}
+
+// withPreset fills the options that aren't set from the preset and the defaults
+func (o CSVOptions) withPreset() (CSVOptions, error) {
+	if o.Preset != "" {
+		preset, ok := csvPresets[o.Preset]
+		if !ok {
+			return o, fmt.Errorf("unknown preset %s", o.Preset)
+		}
+
+		for _, field := range []struct {
+			value  *string
+			preset string
+		}{
+			{&o.Date, preset.Date},
+			{&o.Start, preset.Start},
+			{&o.End, preset.End},
+			{&o.Duration, preset.Duration},
+			{&o.Project, preset.Project},
+			{&o.Note, preset.Note},
+			{&o.DateFormat, preset.DateFormat},
+		} {
+			if *field.value == "" {
+				*field.value = field.preset
+			}
+		}
+	}
+
+	if o.DateFormat == "" {
+		o.DateFormat = "2006-01-02"
+	}
+
+	if o.Delimiter == "" {
+		o.Delimiter = ","
+	}
+
+	if o.Date == "" || o.Start == "" || (o.End == "" && o.Duration == "") {
+		return o, errors.New("the date, start and end or duration columns are required")
+	}
+
+	return o, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	for _, format := range csvTimeFormats {
+		t, err := time.Parse(format, strings.TrimSpace(value))
+		if err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+
+	return 0, fmt.Errorf("can't read time %s", value)
+}
+
+// parseDuration reads durations like 1:30, 01:30:00 or decimal hours like 1.5
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if !strings.Contains(value, ":") {
+		hours, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("can't read duration %s", value)
+		}
+
+		return time.Duration(hours * float64(time.Hour)).Round(time.Second), nil
+	}
+
+	var parts = strings.Split(value, ":")
+
+	var result time.Duration
+
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if i >= len(parts) {
+			break
+		}
+
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || len(parts) > 3 {
+			return 0, fmt.Errorf("can't read duration %s", value)
+		}
+
+		result += time.Duration(n) * unit
+	}
+
+	return result, nil
+}
+
+type importedEvent struct {
+	start  time.Time
+	end    time.Time
+	event  models.EventItem
+	status string
+}
+
+// hasEvent checks if a day already has an event with the same start and end
+func (r *runner) hasEvent(start time.Time, end time.Time) bool {
+	var day = start.Format("2006-01-02")
+
+	for _, item := range r.document.Items[start.Format("2006")][day].Events {
+		if item.Start == start.Format("15:04:05") && item.End == end.Format("15:04:05") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readCSV reads events from a csv file, rows that can't be read get their error as status
+func readCSV(filename string, options CSVOptions) ([]importedEvent, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var reader = csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.Comma = []rune(options.Delimiter)[0]
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("the csv file is empty")
+	}
+
+	var columns = make(map[string]int)
+
+	for i, name := range rows[0] {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+
+	for _, name := range []string{options.Date, options.Start, options.End, options.Duration, options.Project, options.Note} {
+		if _, ok := columns[name]; name != "" && !ok {
+			return nil, fmt.Errorf("column %s not found", name)
+		}
+	}
+
+	var value = func(row []string, name string) string {
+		index, ok := columns[name]
+		if !ok || name == "" || index >= len(row) {
+			return ""
+		}
+
+		return strings.TrimSpace(row[index])
+	}
+
+	var result = make([]importedEvent, 0)
+
+	for _, row := range rows[1:] {
+		var imported = importedEvent{
+			event: models.EventItem{
+				Project: value(row, options.Project),
+				Note:    value(row, options.Note),
+			},
+		}
+
+		date, err := time.Parse(options.DateFormat, value(row, options.Date))
+		if err != nil {
+			imported.status = fmt.Sprintf("error: can't read date %s", value(row, options.Date))
+			result = append(result, imported)
+
+			continue
+		}
+
+		start, err := parseClock(value(row, options.Start))
+		if err != nil {
+			imported.status = "error: " + err.Error()
+			result = append(result, imported)
+
+			continue
+		}
+
+		imported.start = date.Add(start)
+
+		if value(row, options.End) != "" {
+			end, err := parseClock(value(row, options.End))
+			if err != nil {
+				imported.status = "error: " + err.Error()
+				result = append(result, imported)
+
+				continue
+			}
+
+			imported.end = date.Add(end)
+		} else {
+			duration, err := parseDuration(value(row, options.Duration))
+			if err != nil {
+				imported.status = "error: " + err.Error()
+				result = append(result, imported)
+
+				continue
+			}
+
+			imported.end = imported.start.Add(duration)
+		}
+
+		if imported.end.Before(imported.start) || imported.end.Format("2006-01-02") != imported.start.Format("2006-01-02") {
+			imported.status = "error: events can't cross midnight"
+		}
+
+		result = append(result, imported)
+	}
+
+	return result, nil
+}
+
+// ImportCSV adds the events of a csv file, like the exports of Toggl or Clockify, that aren't logged yet.
+// Nothing is added if any row can't be read or when it is a dry run
+func (r *runner) ImportCSV(filename string, options CSVOptions) {
+	options, err := options.withPreset()
+	utils.ErrorHandler(err)
+
+	events, err := readCSV(filename, options)
+	utils.ErrorHandler(err)
+
+	r.importEvents(events, options.DryRun)
+}
+
+// importEvents adds the imported events without a status that aren't duplicates, and prints what happened to each.
+// Nothing is added when any has an error
+func (r *runner) importEvents(events []importedEvent, dryRun bool) {
+	var failed = 0
+
+	for i := range events {
+		if strings.HasPrefix(events[i].status, "error") {
+			failed++
+		}
+	}
+
+	var seen = make(map[string]bool)
+
+	for i, imported := range events {
+		if imported.status != "" {
+			continue
+		}
+
+		var key = imported.start.String() + imported.end.String()
+
+		if seen[key] || r.hasEvent(imported.start, imported.end) {
+			events[i].status = "duplicate"
+			continue
+		}
+
+		seen[key] = true
+
+		if dryRun || failed > 0 {
+			events[i].status = "would add"
+			continue
+		}
+
+		var excluded = r.document.Items[imported.start.Format("2006")][imported.start.Format("2006-01-02")].Excluded
+
+		r.document.AddEvent(imported.start, imported.end, excluded, false, imported.event)
+		events[i].status = "added"
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"Date", "Start", "End", "Project", "Note", "Status"})
+
+	for _, imported := range events {
+		var date, start, end = "", "", ""
+		if !imported.start.IsZero() {
+			date = imported.start.Format("2006-01-02")
+			start = imported.start.Format("15:04:05")
+		}
+
+		if !imported.end.IsZero() {
+			end = imported.end.Format("15:04:05")
+		}
+
+		table.Append([]string{date, start, end, imported.event.Project, imported.event.Note, imported.status})
+	}
+
+	table.Render()
+
+	if failed > 0 {
+		utils.ErrorHandler(fmt.Errorf("%d rows could not be read, nothing was added", failed))
+	}
+}
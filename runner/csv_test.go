@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	d, err := parseDuration("01:30:00")
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	d, err = parseDuration("1:30")
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	d, err = parseDuration("1.25")
+	assert.Nil(t, err)
+	assert.Equal(t, 75*time.Minute, d)
+
+	_, err = parseDuration("an hour")
+	assert.NotNil(t, err)
+	_, err = parseDuration("1:a")
+	assert.NotNil(t, err)
+	_, err = parseDuration("1:2:3:4")
+	assert.NotNil(t, err)
+
+	c, err := parseClock("01:30 PM")
+	assert.Nil(t, err)
+	assert.Equal(t, 13*time.Hour+30*time.Minute, c)
+}
+
+func TestCSVOptions(t *testing.T) {
+	o, err := CSVOptions{Preset: "clockify", Note: "Task"}.withPreset()
+	assert.Nil(t, err)
+	assert.Equal(t, "Start Date", o.Date)
+	assert.Equal(t, "Task", o.Note)
+	assert.Equal(t, "01/02/2006", o.DateFormat)
+
+	_, err = CSVOptions{Preset: "harvest"}.withPreset()
+	assert.NotNil(t, err)
+
+	_, err = CSVOptions{Date: "date"}.withPreset()
+	assert.NotNil(t, err)
+
+	o, err = CSVOptions{Date: "date", Start: "start", Duration: "hours"}.withPreset()
+	assert.Nil(t, err)
+	assert.Equal(t, "2006-01-02", o.DateFormat)
+	assert.Equal(t, ",", o.Delimiter)
+}
+
+func TestImportCSV(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), true, models.EventItem{})
+
+	var filename = "/tmp/timesheet-import.csv"
+
+	var content = "\ufeffUser,Project,Description,Start date,Start time,End date,End time,Duration\n" +
+		"me,a,design,2026-09-07,08:00:00,2026-09-07,10:00:00,02:00:00\n" +
+		"me,a,review,2026-09-07,10:30:00,2026-09-07,12:00:00,01:30:00\n" +
+		"me,b,,2026-09-08,09:00:00,2026-09-08,09:45:00,00:45:00\n" +
+		"me,b,,2026-09-08,09:00:00,2026-09-08,09:45:00,00:45:00\n"
+
+	assert.Nil(t, ioutil.WriteFile(filename, []byte(content), 0600))
+
+	defer os.Remove(filename)
+
+	r.ImportCSV(filename, CSVOptions{Preset: "toggl", DryRun: true})
+	assert.Len(t, d.Items["2026"]["2026-09-07"].Events, 1)
+	assert.Nil(t, d.Items["2026"]["2026-09-08"].Events)
+
+	r.ImportCSV(filename, CSVOptions{Preset: "toggl"})
+	assert.Len(t, d.Items["2026"]["2026-09-07"].Events, 2)
+	assert.Equal(t, models.EventItem{Start: "10:30:00", End: "12:00:00", Project: "a", Note: "review"}, d.Items["2026"]["2026-09-07"].Events[1])
+	assert.Len(t, d.Items["2026"]["2026-09-08"].Events, 1)
+	assert.True(t, d.Items["2026"]["2026-09-07"].Excluded)
+	assert.False(t, d.Items["2026"]["2026-09-08"].Excluded)
+
+	content = "day;from;hours\n07.09.2026;13:00;1.5\n08.09.2026;13:00;lots\n"
+	assert.Nil(t, ioutil.WriteFile(filename, []byte(content), 0600))
+
+	var options = CSVOptions{Date: "day", Start: "from", Duration: "hours", DateFormat: "02.01.2006", Delimiter: ";"}
+	assert.Panics(t, func() { r.ImportCSV(filename, options) })
+	assert.Len(t, d.Items["2026"]["2026-09-07"].Events, 2)
+
+	content = "day;from;hours\n07.09.2026;13:00;1.5\n"
+	assert.Nil(t, ioutil.WriteFile(filename, []byte(content), 0600))
+	r.ImportCSV(filename, options)
+	assert.Equal(t, "14:30:00", d.Items["2026"]["2026-09-07"].Events[2].End)
+
+	options.Project = "client"
+	assert.Panics(t, func() { r.ImportCSV(filename, options) })
+	assert.Panics(t, func() { r.ImportCSV("/tmp/does-not-exist.csv", options) })
+}
@@ -0,0 +1,299 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// problem is something wrong in the document, its path is the keys and indexes leading to it
+type problem struct {
+	path    []string
+	message string
+	fixed   bool
+}
+
+// fixTime returns a time like 8:00 or 8:00:00 as 08:00:00
+func fixTime(value string) (string, bool) {
+	for _, format := range []string{"15:04:05", "15:04"} {
+		t, err := time.Parse(format, strings.TrimSpace(value))
+		if err == nil {
+			return t.Format("15:04:05"), true
+		}
+	}
+
+	return "", false
+}
+
+func (r *runner) checkSettings() []problem {
+	var result = make([]problem, 0)
+
+	var keys = make([]string, 0)
+	for key := range r.document.Configuration {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := checkSetting(key, r.document.Configuration[key]); err != nil {
+			result = append(result, problem{path: []string{"configuration", key}, message: err.Error()})
+		}
+	}
+
+	return result
+}
+
+// checkEvents validates the times of the events or breaks of a day, and tells if they can all be read
+func checkEvents(year string, day string, list string, events []models.EventItem, fix bool) ([]problem, bool) {
+	var result = make([]problem, 0)
+
+	var readable = true
+
+	for i := range events {
+		var path = []string{year, day, list, strconv.Itoa(i)}
+
+		var valid = true
+
+		for _, field := range []struct {
+			name  string
+			value *string
+		}{{"start", &events[i].Start}, {"end", &events[i].End}} {
+			if _, err := utils.TimeFromDateStringAndTimeString2(day, *field.value); err == nil {
+				continue
+			}
+
+			var current = problem{
+				path:    append(append([]string{}, path...), field.name),
+				message: fmt.Sprintf("can't read %s %q", field.name, *field.value),
+			}
+
+			fixed, ok := fixTime(*field.value)
+			if ok && fix {
+				*field.value = fixed
+				current.fixed = true
+			} else {
+				valid = false
+			}
+
+			result = append(result, current)
+		}
+
+		if valid && events[i].End < events[i].Start {
+			result = append(result, problem{path: path, message: "ends before it starts"})
+		}
+
+		readable = readable && valid
+	}
+
+	return result, readable
+}
+
+// checkOverlaps finds events that overlap an earlier one. Duplicates are removed when fixing
+func checkOverlaps(year string, day string, events []models.EventItem, fix bool) ([]models.EventItem, []problem) {
+	var result = make([]problem, 0)
+
+	var order = make([]int, len(events))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return events[order[i]].Start < events[order[j]].Start
+	})
+
+	var removed = make(map[int]bool)
+
+	var latest = -1
+
+	for _, i := range order {
+		if latest >= 0 && events[i].Start < events[latest].End {
+			var path = []string{year, day, "events", strconv.Itoa(i)}
+
+			if sameEvent(events[i], events[latest]) {
+				result = append(result, problem{path: path, message: fmt.Sprintf("is a duplicate of event %d", latest), fixed: fix})
+				removed[i] = fix
+
+				continue
+			}
+
+			result = append(result, problem{path: path, message: fmt.Sprintf("overlaps event %d", latest)})
+		}
+
+		if latest < 0 || events[i].End > events[latest].End {
+			latest = i
+		}
+	}
+
+	var kept = make([]models.EventItem, 0)
+
+	for i, event := range events {
+		if !removed[i] {
+			kept = append(kept, event)
+		}
+	}
+
+	return kept, result
+}
+
+func sameEvent(a models.EventItem, b models.EventItem) bool {
+	if a.Start != b.Start || a.End != b.End || a.Project != b.Project || a.Note != b.Note {
+		return false
+	}
+
+	if a.Billable == nil || b.Billable == nil {
+		return a.Billable == b.Billable
+	}
+
+	return *a.Billable == *b.Billable
+}
+
+// check validates the settings and every day, and makes the safe repairs when fixing: times like 8:00 are written
+// as 08:00:00, days under the wrong year are moved and duplicate events are removed
+func (r *runner) check(fix bool) []problem {
+	var result = r.checkSettings()
+
+	var years = make([]string, 0)
+	for year := range r.document.Items {
+		years = append(years, year)
+	}
+
+	sort.Strings(years)
+
+	type move struct {
+		from string
+		day  string
+	}
+
+	var moves = make([]move, 0)
+
+	for _, year := range years {
+		var days = make([]string, 0)
+		for day := range r.document.Items[year] {
+			days = append(days, day)
+		}
+
+		sort.Strings(days)
+
+		for _, day := range days {
+			var item = r.document.Items[year][day]
+
+			if _, err := time.Parse("2006-01-02", day); err != nil {
+				result = append(result, problem{path: []string{year, day}, message: fmt.Sprintf("%s is not a date like 2006-01-02", day)})
+				continue
+			}
+
+			if day[:4] != year {
+				var current = problem{path: []string{year, day}, message: fmt.Sprintf("is filed under %s instead of %s", year, day[:4])}
+
+				if _, taken := r.document.Items[day[:4]][day]; fix && !taken {
+					moves = append(moves, move{from: year, day: day})
+					current.fixed = true
+				}
+
+				result = append(result, current)
+			}
+
+			var events = make([]models.EventItem, len(item.Events))
+			copy(events, item.Events)
+
+			var breaks = make([]models.EventItem, len(item.Breaks))
+			copy(breaks, item.Breaks)
+
+			eventProblems, readable := checkEvents(year, day, "events", events, fix)
+			result = append(result, eventProblems...)
+
+			breakProblems, _ := checkEvents(year, day, "breaks", breaks, fix)
+			result = append(result, breakProblems...)
+
+			if readable {
+				var overlaps []problem
+				events, overlaps = checkOverlaps(year, day, events, fix)
+				result = append(result, overlaps...)
+			}
+
+			if fix {
+				item.Events = events
+
+				if len(item.Breaks) > 0 {
+					item.Breaks = breaks
+				}
+
+				r.document.Items[year][day] = item
+			}
+		}
+	}
+
+	for _, current := range moves {
+		var year = current.day[:4]
+
+		if _, ok := r.document.Items[year]; !ok {
+			r.document.Items[year] = make(map[string]models.DayItem)
+		}
+
+		r.document.Items[year][current.day] = r.document.Items[current.from][current.day]
+		delete(r.document.Items[current.from], current.day)
+
+		if len(r.document.Items[current.from]) == 0 {
+			delete(r.document.Items, current.from)
+		}
+	}
+
+	return result
+}
+
+// line finds the line of a path, or of the closest value around it when it isn't in the file
+func line(lines map[string]int, path []string) string {
+	for i := len(path); i > 0; i-- {
+		if number, ok := lines[strings.Join(path[:i], "/")]; ok {
+			return strconv.Itoa(number)
+		}
+	}
+
+	return ""
+}
+
+// Doctor validates the settings and every day and shows where the problems are,
+// with fix it makes the repairs that are safe
+func (r *runner) Doctor(fix bool) {
+	var lines = make(map[string]int)
+
+	if locator, ok := r.repository.(models.Locator); ok {
+		found, err := locator.Locate()
+		utils.ErrorHandler(err)
+
+		lines = found
+	}
+
+	var problems = r.check(fix)
+
+	if len(problems) == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"Line", "Where", "Problem", "Fixed"})
+
+	var fixed = 0
+
+	for _, current := range problems {
+		if current.fixed {
+			fixed++
+		}
+
+		table.Append([]string{line(lines, current.path), strings.Join(current.path, "/"), current.message, strconv.FormatBool(current.fixed)})
+	}
+
+	table.SetFooter([]string{"", "", fmt.Sprintf("%d problems", len(problems)), fmt.Sprintf("%d fixed", fixed)})
+
+	table.Render()
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoctor(t *testing.T) {
+	var filename = "/tmp/timesheet-doctor.yaml"
+
+	var content = `schema_version: 1
+configuration:
+  break: thirty
+  rounding: sometimes
+"2019":
+  "2019-03-04":
+    excluded: false
+    events:
+    - start: "8:00"
+      end: "12:00:00"
+    - start: "13:00:00"
+      end: "12:30:00"
+  "2020-01-02":
+    excluded: false
+    events:
+    - start: "08:00:00"
+      end: "10:00:00"
+    - start: "09:00:00"
+      end: "11:00:00"
+    - start: "09:00:00"
+      end: "11:00:00"
+  "2019-13-01":
+    excluded: false
+    events: []
+`
+
+	assert.Nil(t, ioutil.WriteFile(filename, []byte(content), 0600))
+
+	defer os.Remove(filename)
+
+	var repository = models.New(filename)
+
+	d, err := repository.Load()
+	assert.Nil(t, err)
+
+	r := &runner{document: d, repository: repository}
+
+	var messages = make(map[string]string)
+
+	for _, current := range r.check(false) {
+		assert.False(t, current.fixed)
+		messages[line(mustLocate(t, repository), current.path)] = current.message
+	}
+
+	assert.Equal(t, map[string]string{
+		"3":  `break "thirty" is not valid: must be a whole number of minutes`,
+		"4":  "rounding \"sometimes\" is not valid: must be one of none, nearest, up, down",
+		"9":  `can't read start "8:00"`,
+		"11": "ends before it starts",
+		"13": "is filed under 2019 instead of 2020",
+		"18": "overlaps event 0",
+		"20": "is a duplicate of event 1",
+		"22": "2019-13-01 is not a date like 2006-01-02",
+	}, messages)
+
+	r.Doctor(true)
+
+	assert.Equal(t, "08:00:00", d.Items["2019"]["2019-03-04"].Events[0].Start)
+	assert.NotContains(t, d.Items["2019"], "2020-01-02")
+	assert.Len(t, d.Items["2020"]["2020-01-02"].Events, 2)
+
+	var remaining = r.check(false)
+	assert.Len(t, remaining, 5)
+
+	r = &runner{document: &models.Document{}}
+	assert.Len(t, r.check(true), 0)
+	r.Doctor(false)
+}
+
+func mustLocate(t *testing.T, repository models.Repository) map[string]int {
+	lines, err := repository.(models.Locator).Locate()
+	assert.Nil(t, err)
+
+	return lines
+}
@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// RateAdd adds an hourly rate
+func (r *runner) RateAdd(rate models.Rate) {
+	utils.ErrorHandler(r.document.AddRate(rate))
+}
+
+// RateList prints a table of rates
+func (r *runner) RateList() {
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"#", "Project", "Client", "Amount", "Currency", "From"})
+
+	for i, rate := range r.document.Rates {
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			rate.Project,
+			rate.Client,
+			fmt.Sprintf("%.2f", rate.Amount),
+			rate.Currency,
+			rate.From,
+		})
+	}
+
+	table.Render()
+}
+
+// RateRemove removes the rate with the number shown by RateList
+func (r *runner) RateRemove(number int) {
+	utils.ErrorHandler(r.document.RemoveRate(number - 1))
+}
+
+type earning struct {
+	month    string
+	client   string
+	currency string
+	minutes  int
+	amount   float64
+}
+
+// earnings multiplies billed minutes per project and day with the rate in effect,
+// grouped by month, client and currency. Time without a rate is grouped under client "-"
+func (r *runner) earnings() []earning {
+	var rule = r.breakRule()
+
+	var grouped = make(map[string]*earning)
+
+	var result = make([]earning, 0)
+
+	var keys = make([]string, 0)
+
+	for _, entry := range r.days() {
+		for client, net := range r.byClient(r.billable(r.netSegments(rule, entry)), entry.day) {
+			for project, minutes := range r.roundingFor(client).perProject(net) {
+				var current = earning{month: entry.day[:7], client: "-", currency: "-"}
+
+				rate, ok := r.document.RateFor(project, entry.day)
+				if ok {
+					current.client = rate.Client
+					current.currency = rate.Currency
+				}
+
+				var key = fmt.Sprint(current.month, "\x00", current.client, "\x00", current.currency)
+
+				existing, found := grouped[key]
+				if !found {
+					existing = &current
+					grouped[key] = existing
+					keys = append(keys, key)
+				}
+
+				existing.minutes += minutes
+				existing.amount += float64(minutes) / 60 * rate.Amount
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		result = append(result, *grouped[key])
+	}
+
+	return result
+}
+
+// SummaryEarnings shows billable hours and earnings per month and client
+func (r *runner) SummaryEarnings() {
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"Month", "Client", "Hours", "Amount", "Currency"})
+
+	for _, e := range r.earnings() {
+		table.Append([]string{
+			e.month,
+			e.client,
+			utils.IntOfMinutesToString(e.minutes),
+			fmt.Sprintf("%.2f", e.amount),
+			e.currency,
+		})
+	}
+
+	var rounding = r.roundingRule()
+	if rounding.mode != roundingNone {
+		var description = rounding.String()
+		if rounding.scope == roundingScopeDay {
+			description += " and project"
+		}
+
+		for _, client := range r.document.Clients {
+			if client.Rounding != "" || client.RoundingMinutes != 0 || client.RoundingScope != "" {
+				description += " unless the client has its own rule"
+				break
+			}
+		}
+
+		table.SetFooter([]string{"", "", "", "Rounding", description})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarnings(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	d.Configuration["workday"] = "450"
+	d.Configuration["break"] = "0"
+	d.Configuration["rounding"] = "up"
+
+	r.RateAdd(models.Rate{Project: "a", Client: "acme", Amount: 1000, Currency: "NOK", From: "2026-09-01"})
+	r.RateAdd(models.Rate{Project: "b", Client: "acme", Amount: 600, Currency: "NOK"})
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 9, 50, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	r.Add(time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 30, 0, 0, time.UTC), false, models.EventItem{Project: "b"})
+	r.Add(time.Date(2026, 8, 31, 8, 0, 0, 0, time.UTC), time.Date(2026, 8, 31, 9, 0, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+
+	earnings := r.earnings()
+	assert.Len(t, earnings, 2)
+
+	assert.Equal(t, "2026-08", earnings[0].month)
+	assert.Equal(t, "-", earnings[0].client)
+	assert.Equal(t, 0.0, earnings[0].amount)
+
+	assert.Equal(t, "2026-09", earnings[1].month)
+	assert.Equal(t, "acme", earnings[1].client)
+	assert.Equal(t, 150, earnings[1].minutes)
+	assert.InDelta(t, 2300.0, earnings[1].amount, 0.001)
+
+	r.SummaryEarnings()
+	r.RateList()
+	r.RateRemove(1)
+	assert.Len(t, d.Rates, 1)
+	assert.Panics(t, func() { r.RateRemove(2) })
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+// git runs a git command in dir and returns its output
+func git(dir string, args ...string) (string, error) {
+	var command = exec.Command("git", append([]string{"-C", dir}, args...)...)
+
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(output)))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// gitFile returns the file the document is kept in, and the directory of it git runs in
+func (r *runner) gitFile() (string, string) {
+	filer, ok := r.repository.(models.Filer)
+	if !ok || filer.File() == "" {
+		utils.ErrorHandler(errors.New("the storage is not a file, so it can't be kept in git"))
+	}
+
+	filename, err := filepath.Abs(filer.File())
+	utils.ErrorHandler(err)
+
+	return filename, filepath.Dir(filename)
+}
+
+func (r *runner) gitEnabled() bool {
+	enabled, err := strconv.ParseBool(r.setting("git"))
+	utils.ErrorHandler(err)
+
+	return enabled
+}
+
+// commit commits the file if it changed, the directory is made a git repository first when it isn't in one
+func commit(filename string, dir string, message string) error {
+	if _, err := git(dir, "rev-parse", "--show-toplevel"); err != nil {
+		if _, err := git(dir, "init"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := git(dir, "add", "--", filename); err != nil {
+		return err
+	}
+
+	status, err := git(dir, "status", "--porcelain", "--", filename)
+	if err != nil || status == "" {
+		return err
+	}
+
+	_, err = git(dir, "commit", "-m", message, "--", filename)
+
+	return err
+}
+
+// Commit commits the data after a command changed it, when the git setting is on
+func (r *runner) Commit(message string) {
+	if !r.gitEnabled() {
+		return
+	}
+
+	filename, dir := r.gitFile()
+
+	utils.ErrorHandler(commit(filename, dir, "timesheet "+message))
+}
+
+// gitSync commits the file, merges the changes of the remote and pushes the result
+func gitSync(filename string, dir string, remote string) error {
+	if err := commit(filename, dir, "timesheet sync"); err != nil {
+		return err
+	}
+
+	branch, err := git(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	heads, err := git(dir, "ls-remote", "--heads", remote, branch)
+	if err != nil {
+		return err
+	}
+
+	if heads != "" {
+		if _, err := git(dir, "pull", "--no-rebase", "--no-edit", remote, branch); err != nil {
+			return fmt.Errorf("%s, resolve the conflict in %s and commit it before syncing again", err, dir)
+		}
+	}
+
+	_, err = git(dir, "push", remote, branch)
+
+	return err
+}
+
+// Sync commits the data, pulls and merges the changes of the git_remote setting and pushes them back
+func (r *runner) Sync() {
+	filename, dir := r.gitFile()
+
+	utils.ErrorHandler(gitSync(filename, dir, r.setting("git_remote")))
+
+	d, err := r.repository.Load()
+	utils.ErrorHandler(err)
+
+	if d.Items == nil {
+		d.Items = make(map[string]map[string]models.DayItem)
+	}
+
+	*r.document = *d
+
+	fmt.Printf("Synced %s with %s\n", filename, r.setting("git_remote"))
+}
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGit(t *testing.T) {
+	root, err := ioutil.TempDir("", "timesheet-git")
+	assert.Nil(t, err)
+
+	defer os.RemoveAll(root)
+
+	var remote = filepath.Join(root, "remote.git")
+
+	_, err = git(root, "init", "--bare", remote)
+	assert.Nil(t, err)
+
+	var clone = func(name string) *runner {
+		var dir = filepath.Join(root, name)
+
+		_, err := git(root, "clone", remote, dir)
+		assert.Nil(t, err)
+
+		for _, config := range [][]string{{"user.name", name}, {"user.email", name + "@example.com"}} {
+			_, err := git(dir, "config", config[0], config[1])
+			assert.Nil(t, err)
+		}
+
+		var repository = models.New(filepath.Join(dir, "timesheet.yaml"))
+
+		d, err := repository.Load()
+		assert.Nil(t, err)
+
+		d.Configuration = map[string]string{"git": "true"}
+		d.Items = make(map[string]map[string]models.DayItem)
+
+		return &runner{document: d, repository: repository}
+	}
+
+	var save = func(r *runner, message string) {
+		assert.Nil(t, r.repository.Save(r.document))
+		r.Commit(message)
+	}
+
+	laptop := clone("laptop")
+	laptop.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), false, models.EventItem{})
+	save(laptop, "add 2026-09-07 08:00 16:00")
+	save(laptop, "list")
+
+	log, err := git(filepath.Join(root, "laptop"), "log", "--format=%s")
+	assert.Nil(t, err)
+	assert.Equal(t, "timesheet add 2026-09-07 08:00 16:00", log)
+
+	laptop.Sync()
+
+	desktop := clone("desktop")
+	desktop.Sync()
+	assert.Len(t, desktop.document.Items["2026"], 1)
+
+	desktop.Off(time.Date(2026, 9, 8, 0, 0, 0, 0, time.UTC))
+	save(desktop, "off 2026-09-08")
+	desktop.Sync()
+
+	laptop.Sync()
+	assert.Len(t, laptop.document.Items["2026"], 2)
+
+	laptop.Off(time.Date(2026, 9, 9, 0, 0, 0, 0, time.UTC))
+	save(laptop, "off 2026-09-09")
+	desktop.Add(time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 17, 0, 0, 0, time.UTC), false, models.EventItem{})
+	save(desktop, "add 2026-09-07 16:00 17:00")
+
+	laptop.Sync()
+	desktop.Sync()
+	assert.Len(t, desktop.document.Items["2026"], 3)
+	assert.Len(t, desktop.document.Items["2026"]["2026-09-07"].Events, 2)
+
+	laptop.SettingsSet("workday", "480")
+	save(laptop, "setting set workday 480")
+	desktop.SettingsSet("workday", "400")
+	save(desktop, "setting set workday 400")
+
+	laptop.Sync()
+	assert.Panics(t, func() { desktop.Sync() })
+
+	off := &runner{document: &models.Document{}, repository: models.New(filepath.Join(root, "off.yaml"))}
+	off.Commit("list")
+
+	_, err = os.Stat(filepath.Join(root, ".git"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,276 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// describeDay shows the events and breaks of a day a line each, nothing when the day didn't exist
+func describeDay(item *models.DayItem) string {
+	if item == nil {
+		return ""
+	}
+
+	var lines = make([]string, 0)
+
+	if len(item.Events) == 0 {
+		lines = append(lines, "off")
+	}
+
+	for _, event := range item.Events {
+		var line = fmt.Sprintf("%s-%s", event.Start, event.End)
+
+		for _, value := range []string{event.Project, event.Note} {
+			if value != "" {
+				line += " " + value
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	for _, event := range item.Breaks {
+		lines = append(lines, fmt.Sprintf("break %s-%s", event.Start, event.End))
+	}
+
+	if item.Excluded {
+		lines = append(lines, "excluded")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// entryFields returns the yaml names and values of the fields of a client, project, rate or the like
+// except its keys, with the values of fields that aren't set empty
+func entryFields(entry reflect.Value, keys []string) ([]string, []string) {
+	var names, values = make([]string, 0), make([]string, 0)
+
+	for i := 0; i < entry.NumField(); i++ {
+		var key = false
+
+		for _, current := range keys {
+			key = key || current == entry.Type().Field(i).Name
+		}
+
+		if key {
+			continue
+		}
+
+		var name = strings.Split(entry.Type().Field(i).Tag.Get("yaml"), ",")[0]
+
+		var value = ""
+		if field := entry.Field(i); !reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()) {
+			value = fmt.Sprint(field.Interface())
+		}
+
+		names = append(names, name)
+		values = append(values, value)
+	}
+
+	return names, values
+}
+
+// entryKey names an entry by the fields that tell it apart from the others in its list
+func entryKey(label string, entry reflect.Value, keys []string) string {
+	var parts = []string{label}
+
+	for _, key := range keys {
+		if value := fmt.Sprint(entry.FieldByName(key).Interface()); value != "" {
+			parts = append(parts, value)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// describeEntries shows the entries of a list that were removed, added or changed, a line each.
+// Changed entries only show the fields that differ
+func describeEntries(label string, keys []string, before interface{}, after interface{}) ([]string, []string) {
+	var old, current = make([]string, 0), make([]string, 0)
+
+	var oldList, currentList = reflect.ValueOf(before), reflect.ValueOf(after)
+
+	var describe = func(key string, names []string, values []string, only func(i int) bool) string {
+		var parts = make([]string, 0)
+
+		for i, name := range names {
+			if only(i) {
+				parts = append(parts, fmt.Sprintf("%s %s", name, values[i]))
+			}
+		}
+
+		if len(parts) == 0 {
+			return key
+		}
+
+		return fmt.Sprintf("%s: %s", key, strings.Join(parts, ", "))
+	}
+
+	var set = func(values []string) func(i int) bool {
+		return func(i int) bool { return values[i] != "" }
+	}
+
+	var found = make(map[string]bool)
+
+	for i := 0; i < oldList.Len(); i++ {
+		var entry = oldList.Index(i)
+		var key = entryKey(label, entry, keys)
+
+		names, oldValues := entryFields(entry, keys)
+
+		var match = -1
+
+		for j := 0; j < currentList.Len(); j++ {
+			if entryKey(label, currentList.Index(j), keys) == key {
+				match = j
+			}
+		}
+
+		if match < 0 {
+			old = append(old, describe(key, names, oldValues, set(oldValues)))
+			continue
+		}
+
+		found[key] = true
+
+		_, values := entryFields(currentList.Index(match), keys)
+
+		if reflect.DeepEqual(oldValues, values) {
+			continue
+		}
+
+		var differs = func(i int) bool { return oldValues[i] != values[i] }
+
+		old = append(old, describe(key, names, oldValues, differs))
+		current = append(current, describe(key, names, values, differs))
+	}
+
+	for j := 0; j < currentList.Len(); j++ {
+		var entry = currentList.Index(j)
+
+		if key := entryKey(label, entry, keys); !found[key] {
+			names, values := entryFields(entry, keys)
+			current = append(current, describe(key, names, values, set(values)))
+		}
+	}
+
+	return old, current
+}
+
+// describeRest shows the settings that changed, and the overtime rules, clients, projects, rates and invoices
+// that were removed, added or changed
+func describeRest(before *models.Document, after *models.Document) (string, string) {
+	var keys = make([]string, 0)
+
+	for key := range before.Configuration {
+		keys = append(keys, key)
+	}
+
+	for key := range after.Configuration {
+		if _, ok := before.Configuration[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	var old, current = make([]string, 0), make([]string, 0)
+
+	for _, key := range keys {
+		oldValue, wasSet := before.Configuration[key]
+		value, isSet := after.Configuration[key]
+
+		if oldValue == value && wasSet == isSet {
+			continue
+		}
+
+		if wasSet {
+			old = append(old, fmt.Sprintf("%s: %s", key, oldValue))
+		}
+
+		if isSet {
+			current = append(current, fmt.Sprintf("%s: %s", key, value))
+		}
+	}
+
+	for _, list := range []struct {
+		label         string
+		keys          []string
+		before, after interface{}
+	}{
+		{"overtime rule", []string{"Name"}, before.Overtime, after.Overtime},
+		{"client", []string{"Name"}, before.Clients, after.Clients},
+		{"project", []string{"Name"}, before.Projects, after.Projects},
+		{"rate", []string{"Project", "Client", "From"}, before.Rates, after.Rates},
+		{"invoice", []string{"Number"}, before.Invoices, after.Invoices},
+	} {
+		removed, added := describeEntries(list.label, list.keys, list.before, list.after)
+
+		old = append(old, removed...)
+		current = append(current, added...)
+	}
+
+	if before.LastInvoice != after.LastInvoice {
+		old = append(old, fmt.Sprintf("last invoice: %d", before.LastInvoice))
+		current = append(current, fmt.Sprintf("last invoice: %d", after.LastInvoice))
+	}
+
+	return strings.Join(old, "\n"), strings.Join(current, "\n")
+}
+
+// History shows every saved change of a day (yyyy-mm-dd) with what it was before and after,
+// or every change when date is empty
+func (r *runner) History(date string) {
+	if date != "" {
+		_, err := utils.TimeFromDateString(date)
+		utils.ErrorHandler(err)
+	}
+
+	auditor, ok := r.repository.(models.Auditor)
+	if !ok {
+		utils.ErrorHandler(errors.New("changes to this storage are not logged"))
+	}
+
+	changes, err := auditor.History()
+	utils.ErrorHandler(err)
+
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"Time", "Command", "Day", "Before", "After"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+
+	for _, change := range changes {
+		var at = change.Time.Format("2006-01-02 15:04:05")
+
+		var days = make([]string, 0)
+
+		for day := range change.Days {
+			if date == "" || day == date {
+				days = append(days, day)
+			}
+		}
+
+		sort.Strings(days)
+
+		for _, day := range days {
+			table.Append([]string{at, change.Command, day, describeDay(change.Days[day].Before), describeDay(change.Days[day].After)})
+		}
+
+		if date == "" && change.Before != nil {
+			before, after := describeRest(change.Before, change.After)
+
+			table.Append([]string{at, change.Command, "settings", before, after})
+		}
+	}
+
+	table.Render()
+}
@@ -0,0 +1,356 @@
+package runner
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+// ICSOptions selects which calendar events are imported and how
+type ICSOptions struct {
+	Calendar string
+	Match    string
+	Project  string
+	DryRun   bool
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+var icsUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+var icsDuration = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// icsLine folds content lines longer than 75 octets as RFC 5545 requires
+func icsLine(w io.Writer, line string) error {
+	for len(line) > 75 {
+		var cut = 75
+		for cut > 0 && !utf8Start(line[cut]) {
+			cut--
+		}
+
+		if _, err := fmt.Fprintf(w, "%s\r\n ", line[:cut]); err != nil {
+			return err
+		}
+
+		line = line[cut:]
+	}
+
+	_, err := fmt.Fprintf(w, "%s\r\n", line)
+
+	return err
+}
+
+func utf8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// icsSummary describes an event as project and note
+func icsSummary(event models.EventItem) string {
+	switch {
+	case event.Project != "" && event.Note != "":
+		return event.Project + ": " + event.Note
+	case event.Project != "":
+		return event.Project
+	case event.Note != "":
+		return event.Note
+	}
+
+	return "Work"
+}
+
+// icsUID identifies an event by its day, times and project, so it keeps its uid when other events
+// of the day are added or removed and calendars update it instead of adding it again
+func icsUID(start time.Time, end time.Time, project string) string {
+	var sum = sha1.Sum([]byte(project))
+
+	return fmt.Sprintf("%s-%s-%x@timesheet", start.Format("20060102T150405"), end.Format("150405"), sum[:4])
+}
+
+// writeICS writes every event as a VEVENT with floating times, as times are logged without time zone
+func (r *runner) writeICS(w io.Writer) error {
+	var lines = []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//timesheet//timesheet//EN",
+		"CALSCALE:GREGORIAN",
+	}
+
+	var stamp = r.today().UTC().Format("20060102T150405Z")
+
+	for _, entry := range r.days() {
+		for _, item := range entry.item.Events {
+			s, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.Start)
+			if err != nil {
+				return err
+			}
+
+			e, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.End)
+			if err != nil {
+				return err
+			}
+
+			lines = append(lines,
+				"BEGIN:VEVENT",
+				"UID:"+icsUID(s, e, item.Project),
+				"DTSTAMP:"+stamp,
+				"DTSTART:"+s.Format("20060102T150405"),
+				"DTEND:"+e.Format("20060102T150405"),
+				"SUMMARY:"+icsEscaper.Replace(icsSummary(item)),
+			)
+
+			if item.Project != "" {
+				lines = append(lines, "CATEGORIES:"+icsEscaper.Replace(item.Project))
+			}
+
+			lines = append(lines, "END:VEVENT")
+		}
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if err := icsLine(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportICS writes the events to an iCalendar file, or stdout without filename
+func (r *runner) ExportICS(filename string) {
+	if filename == "" {
+		utils.ErrorHandler(r.writeICS(os.Stdout))
+		return
+	}
+
+	f, err := os.Create(filename)
+	utils.ErrorHandler(err)
+
+	defer func() {
+		utils.ErrorHandler(f.Close())
+	}()
+
+	utils.ErrorHandler(r.writeICS(f))
+}
+
+// icsProperty is a content line split into name, parameters and value
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseICSProperty(line string) icsProperty {
+	var property = icsProperty{params: make(map[string]string)}
+
+	var colon = strings.Index(line, ":")
+	if colon < 0 {
+		property.name = strings.ToUpper(line)
+		return property
+	}
+
+	property.value = line[colon+1:]
+
+	var parts = strings.Split(line[:colon], ";")
+	property.name = strings.ToUpper(parts[0])
+
+	for _, param := range parts[1:] {
+		var kv = strings.SplitN(param, "=", 2)
+		if len(kv) == 2 {
+			property.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	return property
+}
+
+// parseICSTime reads a DTSTART or DTEND into local time. UTC and TZID times are converted,
+// floating times are taken as they are. The bool is false for all day dates
+func parseICSTime(property icsProperty) (time.Time, bool, error) {
+	if property.params["VALUE"] == "DATE" || len(property.value) == 8 {
+		t, err := time.Parse("20060102", property.value)
+		return t, false, err
+	}
+
+	if strings.HasSuffix(property.value, "Z") {
+		t, err := time.Parse("20060102T150405Z", property.value)
+		if err != nil {
+			return t, true, err
+		}
+
+		return wallClock(t.In(time.Local)), true, nil
+	}
+
+	if tzid, ok := property.params["TZID"]; ok {
+		location, err := time.LoadLocation(tzid)
+		if err == nil {
+			t, err := time.ParseInLocation("20060102T150405", property.value, location)
+			if err != nil {
+				return t, true, err
+			}
+
+			return wallClock(t.In(time.Local)), true, nil
+		}
+	}
+
+	t, err := time.Parse("20060102T150405", property.value)
+
+	return t, true, err
+}
+
+// wallClock keeps the date and time of day of t, but in UTC like the rest of the times in the document
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// parseICSDuration reads durations like PT1H30M
+func parseICSDuration(value string) (time.Duration, error) {
+	var match = icsDuration.FindStringSubmatch(value)
+	if match == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("can't read duration %s", value)
+	}
+
+	var result time.Duration
+
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if match[i+1] == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return 0, err
+		}
+
+		result += time.Duration(n) * unit
+	}
+
+	return result, nil
+}
+
+// readICS reads the events of a calendar whose summary contains match, ignoring case
+func readICS(f io.Reader, options ICSOptions) ([]importedEvent, error) {
+	var lines = make([]string, 0)
+
+	var scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line = strings.TrimRight(scanner.Text(), "\r")
+
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var result = make([]importedEvent, 0)
+
+	var properties map[string]icsProperty
+
+	var depth = 0
+
+	for _, line := range lines {
+		var property = parseICSProperty(line)
+
+		switch {
+		case property.name == "BEGIN" && strings.ToUpper(property.value) == "VEVENT":
+			properties = make(map[string]icsProperty)
+			depth = 1
+		case property.name == "BEGIN" && depth > 0:
+			depth++
+		case property.name == "END" && depth > 1:
+			depth--
+		case property.name == "END" && depth == 1:
+			depth = 0
+
+			var summary = icsUnescaper.Replace(properties["SUMMARY"].value)
+			if !strings.Contains(strings.ToLower(summary), strings.ToLower(options.Match)) {
+				continue
+			}
+
+			result = append(result, icsEvent(properties, summary, options.Project))
+		case depth == 1:
+			properties[property.name] = property
+		}
+	}
+
+	return result, nil
+}
+
+func icsEvent(properties map[string]icsProperty, summary string, project string) importedEvent {
+	var imported = importedEvent{
+		event: models.EventItem{
+			Project: project,
+			Note:    summary,
+		},
+	}
+
+	start, timed, err := parseICSTime(properties["DTSTART"])
+	if err != nil {
+		imported.status = "error: " + err.Error()
+		return imported
+	}
+
+	imported.start = start
+
+	if !timed {
+		imported.status = "skipped: all day"
+		return imported
+	}
+
+	if _, ok := properties["RRULE"]; ok {
+		imported.status = "skipped: recurring"
+		return imported
+	}
+
+	if end, ok := properties["DTEND"]; ok {
+		imported.end, _, err = parseICSTime(end)
+	} else if duration, ok := properties["DURATION"]; ok {
+		var d time.Duration
+		d, err = parseICSDuration(duration.value)
+		imported.end = start.Add(d)
+	} else {
+		err = errors.New("event has no end")
+	}
+
+	if err != nil {
+		imported.status = "error: " + err.Error()
+		return imported
+	}
+
+	if imported.end.Before(imported.start) || imported.end.Format("2006-01-02") != imported.start.Format("2006-01-02") {
+		imported.status = "error: events can't cross midnight"
+	}
+
+	return imported
+}
+
+// ImportICS adds the timed events of a calendar matching a text as events, with the summary as note.
+// All day and recurring events are skipped
+func (r *runner) ImportICS(options ICSOptions) {
+	f, err := os.Open(options.Calendar)
+	utils.ErrorHandler(err)
+
+	defer f.Close()
+
+	events, err := readICS(f, options)
+	utils.ErrorHandler(err)
+
+	r.importEvents(events, options.DryRun)
+}
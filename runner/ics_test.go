@@ -0,0 +1,130 @@
+package runner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICSLine(t *testing.T) {
+	var buffer bytes.Buffer
+
+	assert.Nil(t, icsLine(&buffer, "SUMMARY:"+strings.Repeat("æ", 50)))
+
+	var lines = strings.Split(strings.TrimSuffix(buffer.String(), "\r\n"), "\r\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, len(lines[0]) <= 75)
+	assert.True(t, strings.HasPrefix(lines[1], " "))
+}
+
+func TestParseICSDuration(t *testing.T) {
+	d, err := parseICSDuration("PT1H30M")
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	d, err = parseICSDuration("P1DT15S")
+	assert.Nil(t, err)
+	assert.Equal(t, 24*time.Hour+15*time.Second, d)
+
+	_, err = parseICSDuration("PT")
+	assert.NotNil(t, err)
+	_, err = parseICSDuration("1 hour")
+	assert.NotNil(t, err)
+}
+
+func TestParseICSTime(t *testing.T) {
+	var local = time.Local
+	time.Local = time.UTC
+
+	defer func() {
+		time.Local = local
+	}()
+
+	start, timed, err := parseICSTime(parseICSProperty("DTSTART;TZID=Europe/Oslo:20260907T080000"))
+	assert.Nil(t, err)
+	assert.True(t, timed)
+	assert.Equal(t, time.Date(2026, 9, 7, 6, 0, 0, 0, time.UTC), start)
+
+	start, _, err = parseICSTime(parseICSProperty("DTSTART:20260907T080000Z"))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), start)
+
+	_, timed, err = parseICSTime(parseICSProperty("DTSTART;VALUE=DATE:20260907"))
+	assert.Nil(t, err)
+	assert.False(t, timed)
+
+	_, _, err = parseICSTime(parseICSProperty("DTSTART:tomorrow"))
+	assert.NotNil(t, err)
+}
+
+func TestICS(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), false, models.EventItem{Project: "a", Note: "design, review"})
+	r.Add(time.Date(2026, 9, 8, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 9, 0, 0, 0, time.UTC), false, models.EventItem{})
+
+	var filename = "/tmp/timesheet.ics"
+
+	defer os.Remove(filename)
+
+	r.ExportICS(filename)
+
+	content, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "DTSTART:20260907T080000\r\n")
+	assert.Contains(t, string(content), "SUMMARY:a: design\\, review\r\n")
+	assert.Contains(t, string(content), "SUMMARY:Work\r\n")
+
+	var uid = "UID:" + icsUID(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), "a")
+	assert.Contains(t, string(content), uid+"\r\n")
+
+	r.Add(time.Date(2026, 9, 7, 7, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 7, 30, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	r.ExportICS(filename)
+
+	content, err = ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), uid+"\r\n")
+	assert.Equal(t, 3, strings.Count(string(content), "UID:"))
+
+	events, err := readICS(bytes.NewReader(content), ICSOptions{Match: "DESIGN"})
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "a: design, review", events[0].event.Note)
+	assert.Equal(t, time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), events[0].end)
+
+	var calendar = "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART:20260909T130000\r\nDURATION:PT45M\r\nSUMMARY:Client X\r\n  sync\r\n" +
+		"BEGIN:VALARM\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20260910\r\nSUMMARY:Client X offsite\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART:20260911T130000\r\nDTEND:20260911T140000\r\nRRULE:FREQ=WEEKLY\r\nSUMMARY:Client X weekly\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART:20260911T100000\r\nDTEND:20260911T110000\r\nSUMMARY:Lunch\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	assert.Nil(t, ioutil.WriteFile(filename, []byte(calendar), 0600))
+
+	r.ImportICS(ICSOptions{Calendar: filename, Match: "client x", Project: "x", DryRun: true})
+	assert.Nil(t, d.Items["2026"]["2026-09-09"].Events)
+
+	r.ImportICS(ICSOptions{Calendar: filename, Match: "client x", Project: "x"})
+	assert.Equal(t, []models.EventItem{{Start: "13:00:00", End: "13:45:00", Project: "x", Note: "Client X sync"}}, d.Items["2026"]["2026-09-09"].Events)
+	assert.Nil(t, d.Items["2026"]["2026-09-10"].Events)
+	assert.Nil(t, d.Items["2026"]["2026-09-11"].Events)
+
+	calendar = "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:20260912T130000\r\nSUMMARY:Client X\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	assert.Nil(t, ioutil.WriteFile(filename, []byte(calendar), 0600))
+	assert.Panics(t, func() { r.ImportICS(ICSOptions{Calendar: filename}) })
+	assert.Panics(t, func() { r.ImportICS(ICSOptions{Calendar: "/tmp/does-not-exist.ics"}) })
+}
@@ -0,0 +1,293 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+const (
+	invoicePerDay  = "day"
+	invoicePerTask = "task"
+
+	invoiceMarkdown = "markdown"
+	invoiceHTML     = "html"
+	invoiceText     = "text"
+)
+
+// InvoiceOptions selects what an invoice covers and how it is written
+type InvoiceOptions struct {
+	Client string
+	Month  string
+	Per    string
+	Format string
+	Output string
+}
+
+// invoiceLine is a line item, fields are exported for the templates
+type invoiceLine struct {
+	Description string
+	Hours       string
+	Minutes     int
+	Rate        float64
+	Amount      float64
+}
+
+type invoiceDocument struct {
+	Number   int
+	Date     string
+	Client   string
+	Address  []string
+	VAT      string
+	Month    string
+	Currency string
+	Rounding string
+	Lines    []invoiceLine
+	Subtotal float64
+	Tax      float64
+	TaxRate  float64
+	Total    float64
+}
+
+const invoiceMarkdownTemplate = `# Invoice {{.Number}}
+
+Date: {{.Date}}  
+Client: {{.Client}}  
+{{range .Address}}{{.}}  
+{{end}}{{if .VAT}}VAT: {{.VAT}}  
+{{end}}Period: {{.Month}}
+
+| Description | Hours | Rate | Amount |
+|---|---:|---:|---:|
+{{range .Lines}}| {{.Description}} | {{.Hours}} | {{printf "%.2f" .Rate}} | {{printf "%.2f" .Amount}} |
+{{end}}| | | Subtotal | {{printf "%.2f" .Subtotal}} {{.Currency}} |
+| | | Tax {{.TaxRate}}% | {{printf "%.2f" .Tax}} {{.Currency}} |
+| | | **Total** | **{{printf "%.2f" .Total}} {{.Currency}}** |
+
+Hours {{.Rounding}}
+`
+
+const invoiceHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Invoice {{.Number}}</title></head>
+<body>
+<h1>Invoice {{.Number}}</h1>
+<p>Date: {{.Date}}<br>Client: {{.Client}}<br>{{range .Address}}{{.}}<br>{{end}}{{if .VAT}}VAT: {{.VAT}}<br>{{end}}Period: {{.Month}}</p>
+<table>
+<tr><th>Description</th><th>Hours</th><th>Rate</th><th>Amount</th></tr>
+{{range .Lines}}<tr><td>{{.Description}}</td><td>{{.Hours}}</td><td>{{printf "%.2f" .Rate}}</td><td>{{printf "%.2f" .Amount}}</td></tr>
+{{end}}<tr><td></td><td></td><td>Subtotal</td><td>{{printf "%.2f" .Subtotal}} {{.Currency}}</td></tr>
+<tr><td></td><td></td><td>Tax {{.TaxRate}}%</td><td>{{printf "%.2f" .Tax}} {{.Currency}}</td></tr>
+<tr><td></td><td></td><th>Total</th><th>{{printf "%.2f" .Total}} {{.Currency}}</th></tr>
+</table>
+<p>Hours {{.Rounding}}</p>
+</body>
+</html>
+`
+
+const invoiceTextTemplate = `INVOICE {{.Number}}
+
+Date:   {{.Date}}
+Client: {{.Client}}
+{{range .Address}}        {{.}}
+{{end}}{{if .VAT}}VAT:    {{.VAT}}
+{{end}}Period: {{.Month}}
+
+{{printf "%-40s %10s %10s %12s" "Description" "Hours" "Rate" "Amount"}}
+{{range .Lines}}{{printf "%-40s %10s %10.2f %12.2f" .Description .Hours .Rate .Amount}}
+{{end}}
+{{printf "%-40s %10s %10s %12.2f" "" "" "Subtotal" .Subtotal}}
+{{printf "%-40s %10s %10s %12.2f" "" "" (printf "Tax %v%%" .TaxRate) .Tax}}
+{{printf "%-40s %10s %10s %12.2f" "" "" "Total" .Total}} {{.Currency}}
+
+Hours {{.Rounding}}
+`
+
+// invoiceLines collects the billable time of a client in a month as line items, per day and project
+// or per task (the note of an event, or its project when it has none)
+func (r *runner) invoiceLines(client string, month string, per string) ([]invoiceLine, string, error) {
+	var rule = r.breakRule()
+
+	var rounding = r.roundingFor(client)
+
+	var currency = ""
+
+	var lines = make(map[string]*invoiceLine)
+
+	var keys = make([]string, 0)
+
+	for _, entry := range r.days() {
+		if entry.day[:7] != month {
+			continue
+		}
+
+		var net = r.byClient(r.billable(r.netSegments(rule, entry)), entry.day)[client]
+
+		var groups = rounding.perKey(net, func(current segment) string {
+			if per == invoicePerTask {
+				return current.event.Project + "\x00" + current.event.Note
+			}
+
+			return current.event.Project
+		})
+
+		for group, minutes := range groups {
+			var parts = strings.SplitN(group, "\x00", 2)
+
+			rate, ok := r.document.RateFor(parts[0], entry.day)
+			if !ok || rate.Client != client || minutes == 0 {
+				continue
+			}
+
+			if currency != "" && currency != rate.Currency {
+				return nil, "", fmt.Errorf("%s is billed in both %s and %s", client, currency, rate.Currency)
+			}
+
+			currency = rate.Currency
+
+			var description = strings.TrimSpace(fmt.Sprint(entry.day, " ", parts[0]))
+			if per == invoicePerTask {
+				description = parts[1]
+				if description == "" {
+					description = parts[0]
+				}
+			}
+
+			var key = fmt.Sprint(description, "\x00", rate.Amount)
+
+			line, found := lines[key]
+			if !found {
+				line = &invoiceLine{Description: description, Rate: rate.Amount}
+				lines[key] = line
+				keys = append(keys, key)
+			}
+
+			line.Minutes += minutes
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, "", fmt.Errorf("no billable time for %s in %s", client, month)
+	}
+
+	sort.Strings(keys)
+
+	var result = make([]invoiceLine, 0)
+
+	for _, key := range keys {
+		var line = *lines[key]
+		line.Hours = utils.IntOfMinutesToString(line.Minutes)
+		line.Amount = float64(line.Minutes) / 60 * line.Rate
+		result = append(result, line)
+	}
+
+	return result, currency, nil
+}
+
+func renderInvoice(w io.Writer, format string, invoice invoiceDocument) error {
+	switch format {
+	case invoiceMarkdown:
+		return texttemplate.Must(texttemplate.New("invoice").Parse(invoiceMarkdownTemplate)).Execute(w, invoice)
+	case invoiceHTML:
+		return htmltemplate.Must(htmltemplate.New("invoice").Parse(invoiceHTMLTemplate)).Execute(w, invoice)
+	case invoiceText:
+		return texttemplate.Must(texttemplate.New("invoice").Parse(invoiceTextTemplate)).Execute(w, invoice)
+	}
+
+	return fmt.Errorf("unknown invoice format %s", format)
+}
+
+// Invoice writes an invoice for a client and month and stores it under the next invoice number
+func (r *runner) Invoice(options InvoiceOptions) {
+	if options.Client == "" {
+		utils.ErrorHandler(errors.New("an invoice needs a client"))
+	}
+
+	_, err := time.Parse("2006-01", options.Month)
+	utils.ErrorHandler(err)
+
+	if options.Per != invoicePerDay && options.Per != invoicePerTask {
+		utils.ErrorHandler(fmt.Errorf("unknown line item grouping %s", options.Per))
+	}
+
+	if options.Format != invoiceMarkdown && options.Format != invoiceHTML && options.Format != invoiceText {
+		utils.ErrorHandler(fmt.Errorf("unknown invoice format %s", options.Format))
+	}
+
+	client, ok := r.document.Client(options.Client)
+	if !ok {
+		client = &models.Client{Name: options.Client}
+	}
+
+	var address []string
+	if client.Address != "" {
+		address = strings.Split(client.Address, "\n")
+	}
+
+	taxRate, err := strconv.ParseFloat(r.setting("tax"), 64)
+	utils.ErrorHandler(err)
+
+	lines, currency, err := r.invoiceLines(options.Client, options.Month, options.Per)
+	utils.ErrorHandler(err)
+
+	var rounding = r.roundingFor(options.Client)
+
+	var description = rounding.String()
+	if rounding.mode != roundingNone && rounding.scope == roundingScopeDay {
+		description += " and " + map[string]string{invoicePerDay: "project", invoicePerTask: "task"}[options.Per]
+	}
+
+	var invoice = invoiceDocument{
+		Date:     r.today().Format("2006-01-02"),
+		Client:   options.Client,
+		Address:  address,
+		VAT:      client.VAT,
+		Month:    options.Month,
+		Currency: currency,
+		Rounding: description,
+		Lines:    lines,
+		TaxRate:  taxRate,
+	}
+
+	for _, line := range lines {
+		invoice.Subtotal += line.Amount
+	}
+
+	invoice.Tax = invoice.Subtotal * taxRate / 100
+	invoice.Total = invoice.Subtotal + invoice.Tax
+
+	var w io.Writer = os.Stdout
+
+	if options.Output != "" {
+		f, err := os.Create(options.Output)
+		utils.ErrorHandler(err)
+
+		defer func() {
+			utils.ErrorHandler(f.Close())
+		}()
+
+		w = f
+	}
+
+	var stored = r.document.AddInvoice(models.Invoice{
+		Client:   invoice.Client,
+		Month:    invoice.Month,
+		Date:     invoice.Date,
+		Total:    invoice.Total,
+		Currency: invoice.Currency,
+	})
+
+	invoice.Number = stored.Number
+
+	utils.ErrorHandler(renderInvoice(w, options.Format, invoice))
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvoice(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+		now:      func() time.Time { return time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC) },
+	}
+
+	d.Configuration["workday"] = "450"
+	d.Configuration["break"] = "0"
+	d.Configuration["tax"] = "25"
+
+	r.RateAdd(models.Rate{Project: "a", Client: "acme", Amount: 1000, Currency: "NOK"})
+	r.RateAdd(models.Rate{Project: "b", Client: "other", Amount: 600, Currency: "NOK"})
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), false, models.EventItem{Project: "a", Note: "design"})
+	r.Add(time.Date(2026, 9, 8, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 9, 0, 0, 0, time.UTC), false, models.EventItem{Project: "a", Note: "design"})
+	r.Add(time.Date(2026, 9, 8, 9, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 9, 30, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	r.Add(time.Date(2026, 9, 8, 10, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 11, 0, 0, 0, time.UTC), false, models.EventItem{Project: "b"})
+
+	lines, currency, err := r.invoiceLines("acme", "2026-09", invoicePerDay)
+	assert.Nil(t, err)
+	assert.Equal(t, "NOK", currency)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "2026-09-07 a", lines[0].Description)
+	assert.Equal(t, 2000.0, lines[0].Amount)
+	assert.Equal(t, 90, lines[1].Minutes)
+
+	lines, _, err = r.invoiceLines("acme", "2026-09", invoicePerTask)
+	assert.Nil(t, err)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "a", lines[0].Description)
+	assert.Equal(t, 30, lines[0].Minutes)
+	assert.Equal(t, "design", lines[1].Description)
+	assert.Equal(t, 180, lines[1].Minutes)
+
+	_, _, err = r.invoiceLines("acme", "2026-10", invoicePerDay)
+	assert.NotNil(t, err)
+
+	var output = "/tmp/timesheet-invoice"
+
+	r.Invoice(InvoiceOptions{Client: "acme", Month: "2026-09", Per: invoicePerDay, Format: invoiceText, Output: output})
+	content, err := ioutil.ReadFile(output)
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), "INVOICE 1")
+	assert.Contains(t, string(content), "Date:   2026-10-01")
+	assert.Equal(t, "2026-10-01", d.Invoices[0].Date)
+	assert.Contains(t, string(content), "4375.00 NOK")
+	os.Remove(output)
+
+	r.Invoice(InvoiceOptions{Client: "acme", Month: "2026-09", Per: invoicePerTask, Format: invoiceMarkdown})
+	assert.Len(t, d.Invoices, 2)
+	assert.Equal(t, 2, d.Invoices[1].Number)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, renderInvoice(&buffer, invoiceHTML, invoiceDocument{Number: 3, Lines: []invoiceLine{{Description: "<b>"}}}))
+	assert.Contains(t, buffer.String(), "&lt;b&gt;")
+	assert.NotNil(t, renderInvoice(&buffer, "pdf", invoiceDocument{}))
+
+	assert.Panics(t, func() {
+		r.Invoice(InvoiceOptions{Client: "acme", Month: "September", Per: invoicePerDay, Format: invoiceText})
+	})
+	assert.Panics(t, func() { r.Invoice(InvoiceOptions{Client: "acme", Month: "2026-09", Per: "week", Format: invoiceText}) })
+	assert.Panics(t, func() { r.Invoice(InvoiceOptions{Client: "acme", Month: "2026-09", Per: invoicePerDay, Format: "pdf"}) })
+	assert.Panics(t, func() { r.Invoice(InvoiceOptions{Month: "2026-09", Per: invoicePerDay, Format: invoiceText}) })
+	assert.Len(t, d.Invoices, 2)
+}
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+func (r *runner) undoer() models.Undoer {
+	undoer, ok := r.repository.(models.Undoer)
+	if !ok {
+		utils.ErrorHandler(errors.New("changes to this storage are not kept, so they can't be undone"))
+	}
+
+	return undoer
+}
+
+func printChanges(verb string, done string, changes []models.Change) {
+	if len(changes) == 0 {
+		fmt.Printf("Nothing to %s\n", verb)
+		return
+	}
+
+	for _, change := range changes {
+		fmt.Printf("%s %q from %s\n", done, change.Command, change.Time.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// Undo reverts the last n changes made by commands
+func (r *runner) Undo(n int) {
+	changes, err := r.undoer().Undo(r.document, n)
+	utils.ErrorHandler(err)
+
+	printChanges("undo", "Undid", changes)
+}
+
+// Redo makes the last n undone changes again
+func (r *runner) Redo(n int) {
+	changes, err := r.undoer().Redo(r.document, n)
+	utils.ErrorHandler(err)
+
+	printChanges("redo", "Redid", changes)
+}
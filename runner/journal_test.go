@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndo(t *testing.T) {
+	var filename = "/tmp/timesheet-undo.yaml"
+
+	for _, name := range []string{filename, filename + ".journal", filename + ".audit"} {
+		os.Remove(name)
+
+		defer os.Remove(name)
+	}
+
+	d := &models.Document{Items: make(map[string]map[string]models.DayItem)}
+	d.AddEvent(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), false, false, models.EventItem{})
+	assert.Nil(t, models.New(filename).Save(d))
+
+	var repository = models.NewJournal(models.New(filename), filename, "off 2026-09-07")
+
+	d, err := repository.Load()
+	assert.Nil(t, err)
+
+	r := &runner{document: d, repository: repository}
+
+	r.Off(time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, repository.Save(d))
+
+	r.Undo(1)
+	assert.Len(t, d.Items["2026"]["2026-09-07"].Events, 1)
+
+	r.Redo(1)
+	assert.Len(t, d.Items["2026"]["2026-09-07"].Events, 0)
+
+	r = &runner{document: d, repository: models.New(filename)}
+
+	assert.Panics(t, func() { r.Undo(1) })
+}
+
+func TestHistory(t *testing.T) {
+	var before, after = &models.DayItem{Events: []models.EventItem{{Start: "08:00:00", End: "16:00:00", Project: "a"}}}, &models.DayItem{Excluded: true, Events: []models.EventItem{}}
+
+	assert.Equal(t, "", describeDay(nil))
+	assert.Equal(t, "08:00:00-16:00:00 a", describeDay(before))
+	assert.Equal(t, "off\nexcluded", describeDay(after))
+
+	old, current := describeRest(
+		&models.Document{Configuration: map[string]string{"workday": "450", "break": "30"}},
+		&models.Document{Configuration: map[string]string{"workday": "480", "break": "30", "tax": "25"}, Projects: []models.Project{{Name: "a"}}},
+	)
+	assert.Equal(t, "workday: 450", old)
+	assert.Equal(t, "tax: 25\nworkday: 480\nproject a", current)
+
+	old, current = describeRest(
+		&models.Document{
+			Clients: []models.Client{{Name: "acme", Rate: 1000, Currency: "NOK"}, {Name: "gone"}},
+			Rates:   []models.Rate{{Amount: 800, Currency: "NOK"}},
+		},
+		&models.Document{
+			Clients: []models.Client{{Name: "acme", Rate: 900, Currency: "NOK"}},
+			Rates:   []models.Rate{{Amount: 800, Currency: "NOK"}, {Project: "a", Amount: 1200, Currency: "NOK", From: "2026-07-01"}},
+		},
+	)
+	assert.Equal(t, "client acme: rate 1000\nclient gone", old)
+	assert.Equal(t, "client acme: rate 900\nrate a 2026-07-01: amount 1200, currency NOK", current)
+
+	r := &runner{document: &models.Document{}, repository: models.New("/tmp/timesheet-history.yaml")}
+
+	assert.Panics(t, func() { r.History("") })
+	assert.Panics(t, func() { r.History("07.09.2026") })
+}
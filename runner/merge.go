@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Merge merges the changes from base to theirs into ours and saves the result as ours, so it can be used
+// as a git merge driver. Ours is kept where both sides changed the same thing, and it fails when they did.
+// Every file has to exist, a missing one would otherwise be read as a side that removed everything
+func (r *runner) Merge(base string, ours string, theirs string) {
+	for _, spec := range []string{base, ours, theirs} {
+		if _, err := os.Stat(models.Path(spec)); os.IsNotExist(err) {
+			utils.ErrorHandler(fmt.Errorf("%s doesn't exist", models.Path(spec)))
+		} else {
+			utils.ErrorHandler(err)
+		}
+	}
+
+	var documents = make([]*models.Document, 0)
+
+	for _, spec := range []string{base, ours, theirs} {
+		repository, err := models.Open(spec)
+		utils.ErrorHandler(err)
+
+		d, err := repository.Load()
+		utils.ErrorHandler(err)
+
+		documents = append(documents, d)
+	}
+
+	merged, conflicts, err := models.Merge(documents[0], documents[1], documents[2])
+	utils.ErrorHandler(err)
+
+	target, err := models.Open(ours)
+	utils.ErrorHandler(err)
+
+	utils.ErrorHandler(target.Save(merged))
+
+	if filer, ok := r.repository.(models.Filer); ok && samePath(filer.File(), models.Path(ours)) {
+		*r.document = *merged
+	}
+
+	if len(conflicts) == 0 {
+		fmt.Printf("Merged %s into %s\n", theirs, ours)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"Where", "Conflict"})
+
+	for _, conflict := range conflicts {
+		table.Append([]string{conflict.Where, conflict.Message})
+	}
+
+	table.Render()
+
+	utils.ErrorHandler(fmt.Errorf("%d conflicts, %s is kept for them", len(conflicts), ours))
+}
+
+func samePath(a string, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+
+	return errA == nil && errB == nil && a == b
+}
@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	var base, ours, theirs = "/tmp/timesheet-merge-base.yaml", "/tmp/timesheet-merge-ours.yaml", "/tmp/timesheet-merge-theirs.yaml"
+
+	for _, filename := range []string{base, ours, theirs} {
+		os.Remove(filename)
+
+		defer os.Remove(filename)
+	}
+
+	d := &models.Document{Items: make(map[string]map[string]models.DayItem)}
+	d.AddEvent(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 12, 0, 0, 0, time.UTC), false, false, models.EventItem{})
+	assert.Nil(t, models.New(base).Save(d))
+
+	d.AddEvent(time.Date(2026, 9, 7, 13, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 0, 0, 0, time.UTC), false, false, models.EventItem{})
+	assert.Nil(t, models.New(ours).Save(d))
+
+	d, _ = models.New(base).Load()
+	d.AddEvent(time.Date(2026, 9, 7, 17, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 18, 0, 0, 0, time.UTC), false, false, models.EventItem{})
+	assert.Nil(t, models.New(theirs).Save(d))
+
+	var repository = models.New(ours)
+
+	current, err := repository.Load()
+	assert.Nil(t, err)
+
+	r := &runner{document: current, repository: repository}
+
+	r.Merge(base, ours, theirs)
+	assert.Len(t, current.Items["2026"]["2026-09-07"].Events, 3)
+
+	merged, err := models.New(ours).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, current.Items, merged.Items)
+
+	d.AddEvent(time.Date(2026, 9, 7, 15, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 17, 0, 0, 0, time.UTC), false, false, models.EventItem{})
+	assert.Nil(t, models.New(theirs).Save(d))
+
+	assert.Panics(t, func() { r.Merge(base, ours, theirs) })
+
+	var typo = "/tmp/timesheet-merge-theirs-typo.yaml"
+
+	content, err := ioutil.ReadFile(ours)
+	assert.Nil(t, err)
+
+	assert.Panics(t, func() { r.Merge(base, ours, typo) })
+
+	_, err = os.Stat(typo)
+	assert.True(t, os.IsNotExist(err))
+
+	unchanged, err := ioutil.ReadFile(ours)
+	assert.Nil(t, err)
+	assert.Equal(t, content, unchanged)
+}
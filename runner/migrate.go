@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"fmt"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+// migrate copies the document of one storage to another that is empty, and checks that the
+// expected and worked hours of every year are the same afterwards. It returns the copy as it was read back
+func migrate(from string, to string) (*models.Document, int, error) {
+	source, err := models.Open(from)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	target, err := models.Open(to)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	d, err := source.Load()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	existing, err := target.Load()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !existing.Empty() {
+		return nil, 0, fmt.Errorf("%s is not empty", to)
+	}
+
+	if err := target.Save(d); err != nil {
+		return nil, 0, err
+	}
+
+	copied, err := models.Open(to)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result, err := copied.Load()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if result.Items == nil {
+		result.Items = make(map[string]map[string]models.DayItem)
+	}
+
+	var days = 0
+
+	for _, year := range d.Items {
+		days += len(year)
+	}
+
+	if d.Empty() {
+		return result, days, nil
+	}
+
+	var before, after = &runner{document: d}, &runner{document: result}
+
+	years, expected, total := before.yearTotals()
+	_, copiedExpected, copiedTotal := after.yearTotals()
+
+	if len(copiedTotal) != len(total) {
+		return nil, days, fmt.Errorf("%s has %d years after migrating, not %d", to, len(copiedTotal), len(total))
+	}
+
+	for _, year := range years {
+		if copiedExpected[year] != expected[year] || copiedTotal[year] != total[year] {
+			return nil, days, fmt.Errorf(
+				"the totals of %s don't match after migrating, %s expected and %s worked became %s and %s",
+				year,
+				utils.IntOfMinutesToString(expected[year]),
+				utils.IntOfMinutesToString(total[year]),
+				utils.IntOfMinutesToString(copiedExpected[year]),
+				utils.IntOfMinutesToString(copiedTotal[year]),
+			)
+		}
+	}
+
+	return result, days, nil
+}
+
+// Migrate copies everything from one storage to another, like yaml:<file> to sqlite:<file>.
+// The target has to be empty. When the target is the storage in use the copy becomes the document,
+// so it is what is saved when the command is done
+func (r *runner) Migrate(from string, to string) {
+	copied, days, err := migrate(from, to)
+	utils.ErrorHandler(err)
+
+	if filer, ok := r.repository.(models.Filer); ok && samePath(filer.File(), models.Path(to)) {
+		*r.document = *copied
+	}
+
+	fmt.Printf("Copied %d days from %s to %s, the totals of every year match\n", days, from, to)
+}
@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	var yamlFile, sqliteFile, backFile = "/tmp/timesheet-migrate.yaml", "/tmp/timesheet-migrate.db", "/tmp/timesheet-migrate-back.yaml"
+
+	for _, filename := range []string{yamlFile, sqliteFile, backFile} {
+		os.Remove(filename)
+
+		defer os.Remove(filename)
+	}
+
+	d := models.Document{
+		Configuration: map[string]string{"workday": "450", "break": "30"},
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	d.AddEvent(time.Date(2025, 12, 30, 8, 0, 0, 0, time.UTC), time.Date(2025, 12, 30, 16, 0, 0, 0, time.UTC), false, false, models.EventItem{Project: "a"})
+	d.AddEvent(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 12, 0, 0, 0, time.UTC), false, false, models.EventItem{Note: "design"})
+	assert.Nil(t, d.AddBreak(time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 15, 0, 0, time.UTC)))
+	d.AddEvent(time.Date(2026, 9, 8, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 0, 0, 0, 0, time.UTC), true, true, models.EventItem{})
+	assert.Nil(t, models.New(yamlFile).Save(&d))
+
+	r := &runner{}
+
+	r.Migrate("yaml:"+yamlFile, "sqlite:"+sqliteFile)
+
+	copied, err := models.NewSQLite(sqliteFile).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, d.Configuration, copied.Configuration)
+	assert.Equal(t, d.Items, copied.Items)
+
+	assert.Panics(t, func() { r.Migrate("yaml:"+yamlFile, "sqlite:"+sqliteFile) })
+
+	_, days, err := migrate("sqlite:"+sqliteFile, backFile)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, days)
+
+	back, err := models.New(backFile).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, d.Items, back.Items)
+
+	_, _, err = migrate("yaml:"+yamlFile, "csv:/tmp/timesheet.csv")
+	assert.NotNil(t, err)
+}
+
+func TestMigrateIntoStorageInUse(t *testing.T) {
+	var from, to = "/tmp/timesheet-migrate-from.yaml", "/tmp/timesheet-migrate-to.yaml"
+
+	for _, filename := range []string{from, to, to + ".journal", to + ".audit"} {
+		os.Remove(filename)
+
+		defer os.Remove(filename)
+	}
+
+	d := models.Document{
+		Configuration: map[string]string{"workday": "420", "tax": "25"},
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	d.AddEvent(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 12, 0, 0, 0, time.UTC), false, false, models.EventItem{Note: "design"})
+	assert.Nil(t, models.New(from).Save(&d))
+
+	var repository = models.NewJournal(models.New(to), to, "migrate")
+
+	current, err := repository.Load()
+	assert.Nil(t, err)
+
+	current.Configuration = map[string]string{"workday": "450", "break": "30"}
+	current.Items = make(map[string]map[string]models.DayItem)
+
+	r := &runner{document: current, repository: repository}
+
+	r.Migrate("yaml:"+from, "yaml:"+to)
+	assert.Nil(t, repository.Save(current))
+
+	copied, err := models.New(to).Load()
+	assert.Nil(t, err)
+	assert.Equal(t, d.Configuration, copied.Configuration)
+	assert.Equal(t, d.Items, copied.Items)
+}
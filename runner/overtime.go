@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+const normalBucket = models.NormalOvertime
+
+// OvertimeAdd adds an overtime rule
+func (r *runner) OvertimeAdd(rule models.OvertimeRule) {
+	utils.ErrorHandler(r.document.AddOvertimeRule(rule))
+}
+
+// OvertimeList prints a table of overtime rules
+func (r *runner) OvertimeList() {
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"Name", "Multiplier", "Daily", "Weekly", "Weekdays", "From", "To", "Excluded"})
+
+	for _, rule := range r.document.Overtime {
+		table.Append([]string{
+			rule.Name,
+			strconv.FormatFloat(rule.Multiplier, 'f', -1, 64),
+			utils.IntOfMinutesToString(rule.DailyThreshold),
+			utils.IntOfMinutesToString(rule.WeeklyThreshold),
+			fmt.Sprint(rule.Weekdays),
+			rule.From,
+			rule.To,
+			strconv.FormatBool(rule.Excluded),
+		})
+	}
+
+	table.Render()
+}
+
+// OvertimeRemove removes an overtime rule
+func (r *runner) OvertimeRemove(name string) {
+	utils.ErrorHandler(r.document.RemoveOvertimeRule(name))
+}
+
+// overtime splits worked minutes per month into the normal bucket and one bucket per overtime rule.
+// When several rules match the same minute the one with the highest multiplier wins
+func (r *runner) overtime() map[string]map[string]int {
+	var rule = r.breakRule()
+
+	var result = make(map[string]map[string]int)
+
+	var weekly = make(map[string]int)
+
+	for _, entry := range r.days() {
+		date, err := utils.TimeFromDateString(entry.day)
+		utils.ErrorHandler(err)
+
+		year, week := date.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%d", year, week)
+
+		var daily = 0
+
+		month, ok := result[entry.day[:7]]
+		if !ok {
+			month = make(map[string]int)
+			result[entry.day[:7]] = month
+		}
+
+		for _, current := range r.netSegments(rule, entry) {
+			for t := current.start; t.Before(current.end); t = t.Add(time.Minute) {
+				daily++
+				weekly[weekKey]++
+
+				month[r.overtimeBucket(t, daily, weekly[weekKey], entry.item.Excluded)]++
+			}
+		}
+	}
+
+	return result
+}
+
+func (r *runner) overtimeBucket(t time.Time, daily int, weekly int, excluded bool) string {
+	var bucket = normalBucket
+
+	var multiplier = 0.0
+
+	for _, rule := range r.document.Overtime {
+		if rule.Multiplier > multiplier && rule.Matches(t, daily, weekly, excluded) {
+			bucket = rule.Name
+			multiplier = rule.Multiplier
+		}
+	}
+
+	return bucket
+}
+
+// SummaryOvertime shows worked hours per month split into normal time and overtime buckets
+func (r *runner) SummaryOvertime() {
+	var buckets = r.overtime()
+
+	table := tablewriter.NewWriter(os.Stdout)
+
+	var header = []string{"Month", "Normal"}
+	for _, rule := range r.document.Overtime {
+		header = append(header, fmt.Sprintf("%s (x%s)", rule.Name, strconv.FormatFloat(rule.Multiplier, 'f', -1, 64)))
+	}
+
+	header = append(header, "Total", "Weighted")
+
+	table.SetHeader(header)
+
+	var months = make([]string, 0)
+	for month := range buckets {
+		months = append(months, month)
+	}
+
+	sort.Strings(months)
+
+	for _, month := range months {
+		var total = buckets[month][normalBucket]
+
+		var weighted = float64(total)
+
+		var row = []string{month, utils.IntOfMinutesToString(total)}
+
+		for _, rule := range r.document.Overtime {
+			var minutes = buckets[month][rule.Name]
+			total += minutes
+			weighted += float64(minutes) * rule.Multiplier
+			row = append(row, utils.IntOfMinutesToString(minutes))
+		}
+
+		row = append(row, utils.IntOfMinutesToString(total), utils.IntOfMinutesToString(int(weighted)))
+
+		table.Append(row)
+	}
+
+	table.Render()
+}
@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOvertime(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	d.Configuration["workday"] = "450"
+	d.Configuration["break"] = "30"
+
+	r.OvertimeAdd(models.OvertimeRule{Name: "daily", Multiplier: 1.5, DailyThreshold: 540})
+	r.OvertimeAdd(models.OvertimeRule{Name: "sunday", Multiplier: 2, Weekdays: []string{"sunday"}})
+
+	// Monday 07:00 - 17:30 is 10h minus 30m break, one hour past the threshold
+	r.Add(time.Date(2026, 9, 7, 7, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 17, 30, 0, 0, time.UTC), false, models.EventItem{})
+	// Sunday is paid double even past the daily threshold
+	r.Add(time.Date(2026, 9, 6, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 6, 18, 0, 0, 0, time.UTC), true, models.EventItem{})
+
+	buckets := r.overtime()
+	assert.Equal(t, 540, buckets["2026-09"][normalBucket])
+	assert.Equal(t, 60, buckets["2026-09"]["daily"])
+	assert.Equal(t, 600, buckets["2026-09"]["sunday"])
+
+	r.SummaryOvertime()
+	r.OvertimeList()
+	r.OvertimeRemove("sunday")
+	assert.Len(t, d.Overtime, 1)
+	assert.Panics(t, func() { r.OvertimeRemove("sunday") })
+}
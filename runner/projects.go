@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// ProjectAdd adds a project
+func (r *runner) ProjectAdd(project models.Project) {
+	utils.ErrorHandler(r.document.AddProject(project))
+}
+
+// ProjectList prints a table of projects
+func (r *runner) ProjectList() {
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"Name", "Billable", "Client", "Budget"})
+
+	for _, project := range r.document.Projects {
+		var budget = ""
+		if project.Budget > 0 {
+			budget = utils.IntOfMinutesToString(int(project.Budget * 60))
+		}
+
+		table.Append([]string{
+			project.Name,
+			strconv.FormatBool(project.Billable),
+			project.Client,
+			budget,
+		})
+	}
+
+	table.Render()
+}
+
+// ProjectEdit updates a field of a project
+func (r *runner) ProjectEdit(name string, field string, value string) {
+	utils.ErrorHandler(r.document.EditProject(name, field, value))
+}
+
+// ProjectRemove removes a project
+func (r *runner) ProjectRemove(name string) {
+	utils.ErrorHandler(r.document.RemoveProject(name))
+}
+
+// billable leaves out segments of events that aren't billed
+func (r *runner) billable(net []segment) []segment {
+	var result = make([]segment, 0)
+
+	for _, current := range net {
+		if r.document.Billable(current.event) {
+			result = append(result, current)
+		}
+	}
+
+	return result
+}
+
+type projectUsage struct {
+	minutes int
+	first   string
+}
+
+// projectUsage sums the worked minutes per project, rounded like the client of the project is billed
+func (r *runner) projectUsage() map[string]projectUsage {
+	var rule = r.breakRule()
+
+	var result = make(map[string]projectUsage)
+
+	for _, entry := range r.days() {
+		for client, net := range r.byClient(r.netSegments(rule, entry), entry.day) {
+			for project, minutes := range r.roundingFor(client).perProject(net) {
+				var usage = result[project]
+				if usage.first == "" {
+					usage.first = entry.day
+				}
+
+				usage.minutes += minutes
+				result[project] = usage
+			}
+		}
+	}
+
+	return result
+}
+
+type budgetStatus struct {
+	budget    int
+	used      int
+	remaining int
+	perWeek   int
+	exhausted string
+}
+
+// budgetStatus works out how much of the budget of a project is used, the minutes used per week
+// since the first day logged on it and the day the budget runs out at that pace
+func (r *runner) budgetStatus(project models.Project, usage map[string]projectUsage) budgetStatus {
+	var today = r.today()
+
+	var status = budgetStatus{
+		budget:    int(project.Budget * 60),
+		used:      usage[project.Name].minutes,
+		exhausted: "-",
+	}
+
+	status.remaining = status.budget - status.used
+
+	if usage[project.Name].first != "" {
+		first, err := utils.TimeFromDateString(usage[project.Name].first)
+		utils.ErrorHandler(err)
+
+		var weeks = today.Sub(first).Hours() / 24 / 7
+		if weeks < 1 {
+			weeks = 1
+		}
+
+		status.perWeek = int(float64(status.used) / weeks)
+	}
+
+	if status.remaining <= 0 {
+		status.exhausted = "exhausted"
+	} else if status.perWeek > 0 {
+		var days = status.remaining * 7 / status.perWeek
+		status.exhausted = today.AddDate(0, 0, days).Format("2006-01-02")
+	}
+
+	return status
+}
+
+// ProjectStatus shows the budget use of a project, or of every project with a budget
+func (r *runner) ProjectStatus(name string) {
+	var projects = make([]models.Project, 0)
+
+	for _, project := range r.document.Projects {
+		if (name == "" && project.Budget > 0) || project.Name == name {
+			projects = append(projects, project)
+		}
+	}
+
+	if name != "" && len(projects) == 0 {
+		utils.ErrorHandler(fmt.Errorf("project %s not found", name))
+	}
+
+	var usage = r.projectUsage()
+
+	table := tablewriter.NewWriter(os.Stdout)
+
+	table.SetHeader([]string{"Project", "Budget", "Used", "Remaining", "Per week", "Exhausted by"})
+
+	for _, project := range projects {
+		var status = r.budgetStatus(project, usage)
+
+		var budget = "-"
+
+		var remaining = "-"
+
+		var exhausted = "-"
+
+		if project.Budget > 0 {
+			budget = utils.IntOfMinutesToString(status.budget)
+			remaining = utils.IntOfMinutesToString(status.remaining)
+			exhausted = status.exhausted
+		}
+
+		table.Append([]string{
+			project.Name,
+			budget,
+			utils.IntOfMinutesToString(status.used),
+			remaining,
+			utils.IntOfMinutesToString(status.perWeek),
+			exhausted,
+		})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjects(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	d.Configuration["workday"] = "60"
+	d.Configuration["break"] = "0"
+
+	var yes = true
+
+	r.ClientAdd(models.Client{Name: "acme"})
+	r.ProjectAdd(models.Project{Name: "internal", Client: "acme"})
+	r.ProjectAdd(models.Project{Name: "a", Client: "acme", Billable: true})
+	r.RateAdd(models.Rate{Client: "acme", Amount: 600, Currency: "NOK"})
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 9, 0, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	r.Add(time.Date(2026, 9, 7, 9, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), false, models.EventItem{Project: "internal"})
+	r.Add(time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 30, 0, 0, time.UTC), false, models.EventItem{Project: "internal", Billable: &yes})
+
+	earnings := r.earnings()
+	assert.Len(t, earnings, 1)
+	assert.Equal(t, 90, earnings[0].minutes)
+
+	lines, _, err := r.invoiceLines("acme", "2026-09", invoicePerDay)
+	assert.Nil(t, err)
+	assert.Len(t, lines, 2)
+
+	minutes, _ := r.workedMinutes(r.breakRule(), r.days()[0])
+	assert.Equal(t, 150, minutes)
+
+	r.ProjectEdit("internal", "billable", "true")
+	assert.Equal(t, 150, r.earnings()[0].minutes)
+
+	r.ProjectList()
+	assert.Panics(t, func() { r.ProjectEdit("b", "billable", "true") })
+	assert.Panics(t, func() { r.ProjectAdd(models.Project{Name: "a"}) })
+	r.ProjectRemove("a")
+	assert.Panics(t, func() { r.ProjectRemove("a") })
+}
+
+func TestProjectStatus(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+		now: func() time.Time {
+			return time.Date(2026, 9, 21, 12, 0, 0, 0, time.UTC)
+		},
+	}
+
+	d.Configuration["workday"] = "60"
+	d.Configuration["break"] = "0"
+
+	r.ProjectAdd(models.Project{Name: "a", Budget: 10})
+	r.ProjectAdd(models.Project{Name: "b"})
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	r.Add(time.Date(2026, 9, 14, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 14, 10, 0, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	r.Add(time.Date(2026, 9, 14, 10, 0, 0, 0, time.UTC), time.Date(2026, 9, 14, 12, 0, 0, 0, time.UTC), false, models.EventItem{Project: "b"})
+
+	var usage = r.projectUsage()
+	assert.Equal(t, 240, usage["a"].minutes)
+	assert.Equal(t, "2026-09-07", usage["a"].first)
+
+	project, _ := d.Project("a")
+	status := r.budgetStatus(*project, usage)
+	assert.Equal(t, 600, status.budget)
+	assert.Equal(t, 360, status.remaining)
+	assert.Equal(t, 115, status.perWeek)
+	assert.Equal(t, "2026-10-12", status.exhausted)
+
+	r.ProjectStatus("")
+	r.ProjectStatus("b")
+	assert.Panics(t, func() { r.ProjectStatus("c") })
+
+	r.Add(time.Date(2026, 9, 15, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 15, 15, 0, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	status = r.budgetStatus(*project, r.projectUsage())
+	assert.Equal(t, -60, status.remaining)
+	assert.Equal(t, "exhausted", status.exhausted)
+}
@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+const (
+	roundingNone    = models.RoundingNone
+	roundingNearest = models.RoundingNearest
+	roundingUp      = models.RoundingUp
+	roundingDown    = models.RoundingDown
+
+	roundingScopeEvent   = models.RoundingScopeEvent
+	roundingScopeDay     = models.RoundingScopeDay
+	roundingScopeProject = models.RoundingScopeProject
+)
+
+// roundingRule rounds billed minutes in reports, the logged times are never touched.
+// It is read from the settings rounding, rounding_minutes and rounding_scope
+type roundingRule struct {
+	mode    string
+	minutes int
+	scope   string
+}
+
+func (r *runner) roundingRule() roundingRule {
+	minutes, err := strconv.Atoi(r.setting("rounding_minutes"))
+	utils.ErrorHandler(err)
+
+	rule, err := newRoundingRule(r.setting("rounding"), minutes, r.setting("rounding_scope"))
+	utils.ErrorHandler(err)
+
+	return rule
+}
+
+// roundingFor returns the rounding rule of a client, the parts it doesn't set come from the settings
+func (r *runner) roundingFor(client string) roundingRule {
+	var rule = r.roundingRule()
+
+	c, ok := r.document.Client(client)
+	if !ok {
+		return rule
+	}
+
+	if c.Rounding != "" {
+		rule.mode = c.Rounding
+	}
+
+	if c.RoundingMinutes != 0 {
+		rule.minutes = c.RoundingMinutes
+	}
+
+	if c.RoundingScope != "" {
+		rule.scope = c.RoundingScope
+	}
+
+	rule, err := newRoundingRule(rule.mode, rule.minutes, rule.scope)
+	utils.ErrorHandler(err)
+
+	return rule
+}
+
+func newRoundingRule(mode string, minutes int, scope string) (roundingRule, error) {
+	if err := models.CheckRounding(mode, minutes, scope); err != nil {
+		return roundingRule{}, err
+	}
+
+	return roundingRule{
+		mode:    mode,
+		minutes: minutes,
+		scope:   scope,
+	}, nil
+}
+
+// round rounds a number of minutes to the increment of the rule
+func (o roundingRule) round(minutes int) int {
+	var rest = minutes % o.minutes
+
+	switch o.mode {
+	case roundingUp:
+		if rest > 0 {
+			return minutes - rest + o.minutes
+		}
+	case roundingDown:
+		return minutes - rest
+	case roundingNearest:
+		if rest*2 >= o.minutes {
+			return minutes - rest + o.minutes
+		}
+
+		return minutes - rest
+	}
+
+	return minutes
+}
+
+// apply rounds the net segments of a day per event, per project or for the whole day
+func (o roundingRule) apply(net []segment) int {
+	var groups = make(map[string]int)
+
+	for _, current := range net {
+		var key = ""
+
+		switch o.scope {
+		case roundingScopeEvent:
+			key = strconv.Itoa(current.index)
+		case roundingScopeProject:
+			key = current.event.Project
+		}
+
+		groups[key] += current.minutes()
+	}
+
+	var total = 0
+
+	for _, minutes := range groups {
+		total += o.round(minutes)
+	}
+
+	return total
+}
+
+// String describes the rule for report footers
+func (o roundingRule) String() string {
+	if o.mode == roundingNone {
+		return "not rounded"
+	}
+
+	var direction = "to nearest"
+	if o.mode != roundingNearest {
+		direction = o.mode + " to"
+	}
+
+	return fmt.Sprintf("rounded %s %dm per %s", direction, o.minutes, o.scope)
+}
+
+// perProject rounds the net segments of a day per event or otherwise per project,
+// so each project can be billed with its own rate
+func (o roundingRule) perProject(net []segment) map[string]int {
+	return o.perKey(net, func(current segment) string {
+		return current.event.Project
+	})
+}
+
+// perKey rounds the net segments of a day per event or otherwise per group given by key
+func (o roundingRule) perKey(net []segment, key func(segment) string) map[string]int {
+	var byEvent = make(map[int]int)
+
+	var keys = make(map[int]string)
+
+	var result = make(map[string]int)
+
+	for _, current := range net {
+		byEvent[current.index] += current.minutes()
+		keys[current.index] = key(current)
+		result[key(current)] += current.minutes()
+	}
+
+	if o.scope == roundingScopeEvent {
+		result = make(map[string]int)
+
+		for index, minutes := range byEvent {
+			result[keys[index]] += o.round(minutes)
+		}
+
+		return result
+	}
+
+	for group, minutes := range result {
+		result[group] = o.round(minutes)
+	}
+
+	return result
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRound(t *testing.T) {
+	up := roundingRule{mode: roundingUp, minutes: 15}
+	assert.Equal(t, 15, up.round(1))
+	assert.Equal(t, 15, up.round(15))
+	assert.Equal(t, 0, up.round(0))
+
+	down := roundingRule{mode: roundingDown, minutes: 15}
+	assert.Equal(t, 0, down.round(14))
+	assert.Equal(t, 30, down.round(44))
+
+	nearest := roundingRule{mode: roundingNearest, minutes: 15}
+	assert.Equal(t, 0, nearest.round(7))
+	assert.Equal(t, 15, nearest.round(8))
+
+	none := roundingRule{mode: roundingNone, minutes: 15}
+	assert.Equal(t, 7, none.round(7))
+	assert.Equal(t, "not rounded", none.String())
+	assert.Equal(t, "rounded up to 15m per day", roundingRule{mode: roundingUp, minutes: 15, scope: roundingScopeDay}.String())
+	assert.Equal(t, "rounded to nearest 6m per event", roundingRule{mode: roundingNearest, minutes: 6, scope: roundingScopeEvent}.String())
+}
+
+func TestRoundingScopes(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	d.Configuration["workday"] = "450"
+	d.Configuration["break"] = "0"
+	d.Configuration["rounding"] = "up"
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 8, 5, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	r.Add(time.Date(2026, 9, 7, 9, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 9, 5, 0, 0, time.UTC), false, models.EventItem{Project: "a"})
+	r.Add(time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 5, 0, 0, time.UTC), false, models.EventItem{Project: "b"})
+
+	var net = r.netSegments(r.breakRule(), r.days()[0])
+
+	assert.Equal(t, 15, r.roundingRule().apply(net))
+
+	d.Configuration["rounding_scope"] = "project"
+	assert.Equal(t, 30, r.roundingRule().apply(net))
+
+	d.Configuration["rounding_scope"] = "event"
+	assert.Equal(t, 45, r.roundingRule().apply(net))
+
+	r.SummaryDay(ReportOptions{})
+
+	d.Configuration["rounding_scope"] = "week"
+	assert.Panics(t, func() { r.roundingRule() })
+
+	d.Configuration["rounding"] = "sideways"
+	assert.Panics(t, func() { r.roundingRule() })
+
+	d.Configuration["rounding"] = "up"
+	d.Configuration["rounding_scope"] = "day"
+	d.Configuration["rounding_minutes"] = "0"
+	assert.Panics(t, func() { r.roundingRule() })
+}
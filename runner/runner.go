@@ -7,7 +7,6 @@ import (
 	"os"
 	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"git.sr.ht/~hjertnes/timesheet/models"
@@ -18,29 +17,77 @@ import (
 
 // Runner methods
 type Runner interface {
-	SettingsList()
+	SettingsList(describe bool)
 	SettingsSet(key string, value string)
+	SettingsUnset(key string)
+	SettingsReset()
 	List()
-	Add(start time.Time, end time.Time, excluded bool)
+	Add(start time.Time, end time.Time, excluded bool, event models.EventItem)
 	Off(date time.Time)
-	Setup()
+	Break(start time.Time, end time.Time)
+	Setup(options SetupOptions)
 	SummaryYear()
-	SummaryDay()
+	SummaryDay(options ReportOptions)
+	SummaryOvertime()
+	OvertimeAdd(rule models.OvertimeRule)
+	OvertimeList()
+	OvertimeRemove(name string)
+	SummaryEarnings()
+	RateAdd(rate models.Rate)
+	RateList()
+	RateRemove(number int)
+	Invoice(options InvoiceOptions)
+	ProjectAdd(project models.Project)
+	ProjectList()
+	ProjectEdit(name string, field string, value string)
+	ProjectRemove(name string)
+	ClientAdd(client models.Client)
+	ClientList()
+	ClientEdit(name string, field string, value string)
+	ClientRemove(name string)
+	ProjectStatus(name string)
+	ImportCSV(filename string, options CSVOptions)
+	ImportICS(options ICSOptions)
+	ExportICS(filename string)
+	ImportTimeclock(filename string, dryRun bool)
+	ExportTimeclock(filename string)
+	ExportXLSX(filename string)
+	Migrate(from string, to string)
+	Doctor(fix bool)
+	Undo(n int)
+	Redo(n int)
+	History(date string)
+	Commit(message string)
+	Sync()
+	Merge(base string, ours string, theirs string)
 }
 
 type runner struct {
-	document *models.Document
-	reader   read.Read
+	document   *models.Document
+	reader     read.Read
+	repository models.Repository
+	now        func() time.Time
 }
 
-// New constructor
-func New(d *models.Document, r read.Read) Runner {
+// New constructor, repository is where the document was loaded from
+func New(d *models.Document, r read.Read, repository models.Repository) Runner {
 	return &runner{
-		reader:   r,
-		document: d,
+		reader:     r,
+		document:   d,
+		repository: repository,
+		now:        time.Now,
 	}
 }
 
+func (r *runner) today() time.Time {
+	if r.now == nil {
+		return time.Now()
+	}
+
+	return r.now()
+}
+
+// settingToInt reads a setting as a number, settings that aren't set use their default
 func (r *runner) settingToInt(name string) int {
 	var err error
 
@@ -48,7 +95,12 @@ func (r *runner) settingToInt(name string) int {
 
 	setting, ok := r.document.Configuration[name]
 	if !ok {
-		err = errors.New("Key not found")
+		known, found := definition(name)
+		if !found {
+			err = errors.New("Key not found")
+		}
+
+		setting = known.fallback
 	}
 	utils.ErrorHandler(err)
 	result, err = strconv.Atoi(setting)
@@ -62,57 +114,93 @@ func (r *runner) getSettings() (int, int) {
 	return r.settingToInt("workday"), r.settingToInt("break")
 }
 
-// SettingsList prints a table of settings
-func (r *runner) SettingsList() {
-	table := tablewriter.NewWriter(os.Stdout)
+type dayEntry struct {
+	day  string
+	item models.DayItem
+}
 
-	table.SetHeader([]string{"Key", "Value"})
+// days returns every logged day in chronological order with its events sorted by start
+func (r *runner) days() []dayEntry {
+	var result = make([]dayEntry, 0)
 
-	for key, v := range r.document.Configuration {
-		table.Append([]string{key, v})
+	for _, yearValue := range r.document.Items {
+		for day, dayItem := range yearValue {
+			var events = make([]models.EventItem, len(dayItem.Events))
+			copy(events, dayItem.Events)
+
+			sort.SliceStable(events, func(i, j int) bool {
+				return events[i].Start < events[j].Start
+			})
+
+			dayItem.Events = events
+
+			result = append(result, dayEntry{day: day, item: dayItem})
+		}
 	}
 
-	table.Render()
-}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].day < result[j].day
+	})
 
-// SettingsSet adds or updates a setting
-func (r *runner) SettingsSet(key string, value string) {
-	r.document.Configuration[key] = value
+	return result
 }
 
-// List lists events
+// List lists events in chronological order
 func (r *runner) List() {
 	table := tablewriter.NewWriter(os.Stdout)
 
 	table.SetHeader([]string{"Start", "End", "Off", "Excluded"})
 
-	for _, yearValue := range r.document.Items {
-		for day, dayItem := range yearValue {
-			for _, item := range dayItem.Events {
-				table.Append([]string{
-					fmt.Sprint(day, " ", item.Start),
-					fmt.Sprint(day, " ", item.End),
-					strconv.FormatBool(false),
-					strconv.FormatBool(dayItem.Excluded),
-				})
-			}
-			if len(dayItem.Events) == 0 {
-				table.Append([]string{
-					fmt.Sprint(day),
-					fmt.Sprint(day),
-					strconv.FormatBool(true),
-					strconv.FormatBool(dayItem.Excluded),
-				})
-			}
+	table.AppendBulk(r.listRows())
+
+	table.Render()
+}
+
+// listRows returns a row per event, or per day off, in chronological order
+func (r *runner) listRows() [][]string {
+	var rows = make([][]string, 0)
+
+	for _, entry := range r.days() {
+		for _, item := range entry.item.Events {
+			rows = append(rows, []string{
+				fmt.Sprint(entry.day, " ", item.Start),
+				fmt.Sprint(entry.day, " ", item.End),
+				strconv.FormatBool(false),
+				strconv.FormatBool(entry.item.Excluded),
+			})
+		}
+		if len(entry.item.Events) == 0 {
+			rows = append(rows, []string{
+				fmt.Sprint(entry.day),
+				fmt.Sprint(entry.day),
+				strconv.FormatBool(true),
+				strconv.FormatBool(entry.item.Excluded),
+			})
 		}
 	}
 
-	table.Render()
+	return rows
 }
 
-//Add add event
-func (r *runner) Add(start time.Time, end time.Time, excluded bool) {
-	r.document.Add(start, end, excluded, false)
+//Add add event, warns when it puts its project over budget
+func (r *runner) Add(start time.Time, end time.Time, excluded bool, event models.EventItem) {
+	r.document.AddEvent(start, end, excluded, false, event)
+
+	project, ok := r.document.Project(event.Project)
+	if !ok || project.Budget == 0 {
+		return
+	}
+
+	var status = r.budgetStatus(*project, r.projectUsage())
+	if status.remaining < 0 {
+		fmt.Fprintf(
+			os.Stderr,
+			"warning: project %s is over budget, %s used of %s\n",
+			project.Name,
+			utils.IntOfMinutesToString(status.used),
+			utils.IntOfMinutesToString(status.budget),
+		)
+	}
 }
 
 // Off add a day as "off"
@@ -120,116 +208,127 @@ func (r *runner) Off(date time.Time) {
 	r.document.Add(date, date, false, true)
 }
 
-// Setup settings
-func (r *runner) Setup() {
-	fmt.Println("Setup")
-	fmt.Println("This will replace your current settings but not your data")
-	fmt.Print("Work day in minutes: ")
+// Break logs a break, which replaces the configured break on that day
+func (r *runner) Break(start time.Time, end time.Time) {
+	utils.ErrorHandler(r.document.AddBreak(start, end))
+}
 
-	var workDayMinutes = r.reader.Execute(os.Stdin)
+// yearTotals returns the logged years in order with the expected and worked minutes of each
+func (r *runner) yearTotals() ([]string, map[string]int, map[string]int) {
+	var expectation = r.expectation()
 
-	fmt.Print("Break in minutes: ")
+	var rule = r.breakRule()
 
-	var breakInMinutes = r.reader.Execute(os.Stdin)
+	var years = make([]string, 0)
 
-	r.document.Configuration["workday"] = strings.Trim(workDayMinutes, "\n")
-	r.document.Configuration["break"] = strings.Trim(breakInMinutes, "\n")
-}
+	var expected = make(map[string]int)
 
-// SummaryYear show summary per year with difference between expected hours and actual hours
-func (r *runner) SummaryYear() {
-	var workday, breaktime = r.getSettings()
+	var total = make(map[string]int)
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Year", "Expected", "Total", "Difference"})
+	for _, entry := range r.days() {
+		var year = entry.day[:4]
 
-	var data = make([][]string, 0)
+		if _, ok := total[year]; !ok {
+			years = append(years, year)
+			total[year] = 0
+		}
 
-	for year, yearValue := range r.document.Items {
-		var numberOfDays int = 0
-		var expected int = 0
-		var total int = 0
+		expected[year] += expectation.minutes(entry)
 
-		for day, dayItem := range yearValue {
-			if !dayItem.Excluded {
-				numberOfDays++
-			}
-			for _, item := range dayItem.Events {
-				s, err := utils.TimeFromDateStringAndTimeString2(day, item.Start)
-				utils.ErrorHandler(err)
-				e, err := utils.TimeFromDateStringAndTimeString2(day, item.End)
-				utils.ErrorHandler(err)
-
-				var diff = e.Sub(s)
-				total += int(diff.Minutes())
-			}
+		var minutes, deduction = r.workedMinutes(rule, entry)
+		total[year] += minutes - deduction
+	}
 
-		}
+	return years, expected, total
+}
 
-		expected = numberOfDays * workday
+// SummaryYear show summary per year with difference between expected hours and actual hours
+func (r *runner) SummaryYear() {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Year", "Expected", "Total", "Difference"})
 
-		total -= (numberOfDays * breaktime)
+	var years, expected, total = r.yearTotals()
 
-		var diff int = total - expected
+	for _, year := range years {
+		var diff int = total[year] - expected[year]
 
-		data = append(data, []string{
+		table.Append([]string{
 			year,
-			utils.IntOfMinutesToString(expected),
-			utils.IntOfMinutesToString(total),
+			utils.IntOfMinutesToString(expected[year]),
+			utils.IntOfMinutesToString(total[year]),
 			utils.IntOfMinutesToString(diff),
 		})
 	}
 
-	sort.SliceStable(data, func(i, j int) bool {
-		return data[i][0] < data[j][0]
-	})
-
-	for _, e := range data {
-		table.Append(e)
-	}
-
 	table.Render()
 }
 
-// SummaryDay shows list of dates and sum of hours on that day
-func (r *runner) SummaryDay() {
-	var _, breaktime = r.getSettings()
+// ReportOptions filters and groups reports by client
+type ReportOptions struct {
+	Client   string
+	ByClient bool
+}
+
+// SummaryDay shows list of dates and sum of hours on that day, rounded by the rounding settings
+// or the rounding of the client when filtering on one
+func (r *runner) SummaryDay(options ReportOptions) {
+	var rule = r.breakRule()
+
+	var rounding = r.roundingFor(options.Client)
 
 	table := tablewriter.NewWriter(os.Stdout)
 
-	table.SetHeader([]string{"Date", "Hours"})
+	if options.ByClient {
+		table.SetHeader([]string{"Date", "Client", "Hours"})
+	} else {
+		table.SetHeader([]string{"Date", "Hours"})
+	}
+
+	for _, entry := range r.days() {
+		var net = r.netSegments(rule, entry)
 
-	var data = make([][]string, 0)
+		if options.Client != "" {
+			net = r.byClient(net, entry.day)[options.Client]
+		}
 
-	for _, yearValue := range r.document.Items {
-		for day, dayItem := range yearValue {
-			var total int = 0
-			for _, item := range dayItem.Events {
-				s, err := utils.TimeFromDateStringAndTimeString2(day, item.Start)
-				utils.ErrorHandler(err)
-				e, err := utils.TimeFromDateStringAndTimeString2(day, item.End)
-				utils.ErrorHandler(err)
-
-				var diff = e.Sub(s)
-				total += int(diff.Minutes())
-			}
-			if !dayItem.Excluded {
-				total -= breaktime
+		if !options.ByClient {
+			var total = rounding.apply(net)
+			if total > 0 {
+				table.Append([]string{entry.day, utils.IntOfMinutesToString(total)})
 			}
+
+			continue
+		}
+
+		var clients = r.byClient(net, entry.day)
+
+		for _, client := range sortedKeys(clients) {
+			var total = r.roundingFor(client).apply(clients[client])
 			if total > 0 {
-				data = append(data, []string{day, utils.IntOfMinutesToString(total)})
+				table.Append([]string{entry.day, client, utils.IntOfMinutesToString(total)})
 			}
+		}
+	}
 
+	if rounding.mode != roundingNone {
+		if options.ByClient {
+			table.SetFooter([]string{"", "Rounding", rounding.String()})
+		} else {
+			table.SetFooter([]string{"Rounding", rounding.String()})
 		}
 	}
 
-	sort.SliceStable(data, func(i, j int) bool {
-		return data[i][0] < data[j][0]
-	})
+	table.Render()
+}
 
-	for _, e := range data {
-		table.Append(e)
+func sortedKeys(m map[string][]segment) []string {
+	var result = make([]string, 0)
+
+	for key := range m {
+		result = append(result, key)
 	}
 
-	table.Render()
+	sort.Strings(result)
+
+	return result
 }
@@ -25,7 +25,7 @@ func TestNew(t *testing.T) {
 		Items:         make(map[string]map[string]models.DayItem),
 	}
 	r := ReadMock{}
-	_ = New(&d, r)
+	_ = New(&d, r, models.New("/tmp/filename"))
 
 }
 
@@ -91,7 +91,8 @@ func TestSettingsList(t *testing.T) {
 		document: &d,
 	}
 
-	r.SettingsList()
+	r.SettingsList(false)
+	r.SettingsList(true)
 }
 
 func TestSettingsSet(t *testing.T) {
@@ -122,7 +123,7 @@ func TestAdd(t *testing.T) {
 		document: &d,
 	}
 
-	r.Add(time.Now(), time.Now(), false)
+	r.Add(time.Now(), time.Now(), false, models.EventItem{})
 	v := d.Items["2020"]
 	assert.NotNil(t, v)
 }
@@ -156,13 +157,31 @@ func TestList(t *testing.T) {
 		document: &d,
 	}
 
-	r.Add(time.Now(), time.Now(), false)
-	r.Add(time.Now(), time.Now(), true)
+	r.Add(time.Now(), time.Now(), false, models.EventItem{})
+	r.Add(time.Now(), time.Now(), true, models.EventItem{})
 	r.List()
 	r.Off(time.Now())
 	r.List()
 }
 
+func TestListRows(t *testing.T) {
+	d := models.Document{Items: make(map[string]map[string]models.DayItem)}
+
+	r := &runner{document: &d}
+
+	r.Add(time.Date(2026, 9, 8, 13, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 16, 0, 0, 0, time.UTC), false, models.EventItem{})
+	r.Add(time.Date(2026, 9, 8, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 12, 0, 0, 0, time.UTC), false, models.EventItem{})
+	r.Off(time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC))
+	r.Add(time.Date(2025, 12, 31, 8, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC), true, models.EventItem{})
+
+	assert.Equal(t, [][]string{
+		{"2025-12-31 08:00:00", "2025-12-31 12:00:00", "false", "true"},
+		{"2026-09-07", "2026-09-07", "true", "false"},
+		{"2026-09-08 08:00:00", "2026-09-08 12:00:00", "false", "false"},
+		{"2026-09-08 13:00:00", "2026-09-08 16:00:00", "false", "false"},
+	}, r.listRows())
+}
+
 func TestSummary(t *testing.T) {
 	d := models.Document{
 		Configuration: make(map[string]string),
@@ -178,9 +197,9 @@ func TestSummary(t *testing.T) {
 	d.Configuration["workday"] = "1"
 	d.Configuration["break"] = "2"
 
-	r.Add(time.Now(), time.Now(), false)
+	r.Add(time.Now(), time.Now(), false, models.EventItem{})
 	r.SummaryYear()
-	r.Add(time.Now(), time.Now(), true)
+	r.Add(time.Now(), time.Now(), true, models.EventItem{})
 	r.SummaryYear()
 	r.Off(time.Now())
 	r.SummaryYear()
@@ -202,11 +221,11 @@ func TestSummaryDay(t *testing.T) {
 	d.Configuration["workday"] = "1"
 	d.Configuration["break"] = "2"
 
-	r.Add(time.Now(), time.Now(), false)
-	r.SummaryDay()
-	r.Add(time.Now(), time.Now(), true)
-	r.SummaryDay()
+	r.Add(time.Now(), time.Now(), false, models.EventItem{})
+	r.SummaryDay(ReportOptions{})
+	r.Add(time.Now(), time.Now(), true, models.EventItem{})
+	r.SummaryDay(ReportOptions{})
 	r.Off(time.Now())
-	r.SummaryDay()
+	r.SummaryDay(ReportOptions{})
 
 }
@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+// parseSchedule reads the minutes expected per weekday from a list like mon=450,fri=240
+func parseSchedule(value string) (map[time.Weekday]int, error) {
+	var result = make(map[time.Weekday]int)
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pair := strings.SplitN(part, "=", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("%s should be like mon=450", part)
+		}
+
+		weekday, err := models.ParseWeekday(strings.TrimSpace(pair[0]))
+		if err != nil {
+			return nil, err
+		}
+
+		minutes, err := strconv.Atoi(strings.TrimSpace(pair[1]))
+		if err != nil || minutes < 0 {
+			return nil, fmt.Errorf("%s should be a whole number of minutes", pair[1])
+		}
+
+		result[weekday] = minutes
+	}
+
+	return result, nil
+}
+
+// parseHolidays reads a list of days (yyyy-mm-dd) separated by commas
+func parseHolidays(value string) (map[string]bool, error) {
+	var result = make(map[string]bool)
+
+	for _, day := range strings.Split(value, ",") {
+		day = strings.TrimSpace(day)
+		if day == "" {
+			continue
+		}
+
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			return nil, fmt.Errorf("%s is not a date like 2006-01-02", day)
+		}
+
+		result[day] = true
+	}
+
+	return result, nil
+}
+
+func checkSchedule(value string) error {
+	_, err := parseSchedule(value)
+
+	return err
+}
+
+func checkHolidays(value string) error {
+	_, err := parseHolidays(value)
+
+	return err
+}
+
+func checkTimezone(value string) error {
+	_, err := time.LoadLocation(value)
+
+	return err
+}
+
+// Location returns the time zone of the timezone setting, dates like today are in it.
+// When the setting isn't a time zone the local one is returned with the error, so the setting can still be fixed
+func Location(d *models.Document) (*time.Location, error) {
+	value, ok := d.Configuration["timezone"]
+	if !ok {
+		known, _ := definition("timezone")
+		value = known.fallback
+	}
+
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return time.Local, err
+	}
+
+	return location, nil
+}
+
+// expectation is how many minutes of work are expected on a day. It is read from the settings
+// workday, schedule and holidays
+type expectation struct {
+	workday  int
+	schedule map[time.Weekday]int
+	holidays map[string]bool
+}
+
+func (r *runner) expectation() expectation {
+	var workday, _ = r.getSettings()
+
+	var result = expectation{workday: workday}
+
+	var err error
+
+	if value := r.setting("schedule"); value != "" {
+		result.schedule, err = parseSchedule(value)
+		utils.ErrorHandler(err)
+	}
+
+	result.holidays, err = parseHolidays(r.setting("holidays"))
+	utils.ErrorHandler(err)
+
+	return result
+}
+
+// minutes expected on a day, nothing on excluded days and holidays. Without a schedule every day expects a workday
+func (e expectation) minutes(entry dayEntry) int {
+	if entry.item.Excluded || e.holidays[entry.day] {
+		return 0
+	}
+
+	if e.schedule == nil {
+		return e.workday
+	}
+
+	date, err := utils.TimeFromDateString(entry.day)
+	utils.ErrorHandler(err)
+
+	return e.schedule[date.Weekday()]
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := parseSchedule("mon=450, Friday=240,")
+	assert.Nil(t, err)
+	assert.Equal(t, map[time.Weekday]int{time.Monday: 450, time.Friday: 240}, schedule)
+
+	for _, value := range []string{"mon", "mon=", "mon=-1", "someday=450"} {
+		_, err := parseSchedule(value)
+		assert.NotNil(t, err, value)
+	}
+
+	holidays, err := parseHolidays("2026-12-25,2026-12-26")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"2026-12-25": true, "2026-12-26": true}, holidays)
+
+	_, err = parseHolidays("2026-12-25,christmas")
+	assert.NotNil(t, err)
+}
+
+func TestExpectation(t *testing.T) {
+	d := models.Document{
+		Configuration: map[string]string{"workday": "450", "break": "0"},
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	for day := 7; day <= 12; day++ {
+		r.Add(time.Date(2026, 9, day, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, day, 9, 0, 0, 0, time.UTC), day == 8, models.EventItem{})
+	}
+
+	_, expected, _ := r.yearTotals()
+	assert.Equal(t, 5*450, expected["2026"])
+
+	d.Configuration["schedule"] = "mon=480,tue=480,fri=240"
+	d.Configuration["holidays"] = "2026-09-11"
+
+	_, expected, _ = r.yearTotals()
+	assert.Equal(t, 480, expected["2026"])
+
+	location, err := Location(&d)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Local, location)
+
+	d.Configuration["timezone"] = "Europe/Oslo"
+
+	location, err = Location(&d)
+	assert.Nil(t, err)
+	assert.Equal(t, "Europe/Oslo", location.String())
+
+	d.Configuration["timezone"] = "Europe/Olso"
+
+	location, err = Location(&d)
+	assert.NotNil(t, err)
+	assert.Equal(t, time.Local, location)
+}
@@ -0,0 +1,303 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~hjertnes/timesheet/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// settingDefinition is a known setting, its default and how its value is checked
+type settingDefinition struct {
+	key         string
+	fallback    string
+	description string
+	check       func(value string) error
+}
+
+// settingsSchema is every setting that is read, in the order they are listed
+var settingsSchema = []settingDefinition{
+	{"workday", "450", "minutes of work expected on a day", checkMinutes},
+	{"schedule", "", "minutes of work expected per weekday like mon=450,fri=240, other weekdays expect none. Empty expects a workday every day", checkSchedule},
+	{"holidays", "", "days (yyyy-mm-dd) separated by commas where no work is expected", checkHolidays},
+	{"timezone", "Local", "time zone of dates like today, like Europe/Oslo", checkTimezone},
+	{"break", "30", "minutes of break deducted from a day", checkMinutes},
+	{"break_mode", breakModeFixed, "fixed deducts the whole break, gaps deducts what the gaps between events don't cover", checkOneOf(breakModeFixed, breakModeGaps)},
+	{"break_after", "0", "only deduct the break from days with more minutes worked than this", checkMinutes},
+	{"break_days_off", "true", "deduct the break from days off too", checkBool},
+	{"rounding", roundingNone, "how worked time is rounded in reports and invoices", checkOneOf(roundingNone, roundingNearest, roundingUp, roundingDown)},
+	{"rounding_minutes", "15", "minutes worked time is rounded to", checkPositive},
+	{"rounding_scope", roundingScopeDay, "what is rounded, every event, the total of a day or of a project on a day", checkOneOf(roundingScopeEvent, roundingScopeDay, roundingScopeProject)},
+	{"tax", "0", "tax added to invoices in percent", checkPercent},
+	{"git", "false", "commit the data to the git repository it is in after every change", checkBool},
+	{"git_remote", "origin", "the git remote sync pulls from and pushes to", checkNotEmpty},
+}
+
+// Setting describes a setting timesheet uses
+type Setting struct {
+	Key         string
+	Default     string
+	Description string
+}
+
+// Settings returns every setting timesheet uses
+func Settings() []Setting {
+	var result = make([]Setting, 0)
+
+	for _, current := range settingsSchema {
+		result = append(result, Setting{Key: current.key, Default: current.fallback, Description: current.description})
+	}
+
+	return result
+}
+
+func definition(key string) (settingDefinition, bool) {
+	for _, current := range settingsSchema {
+		if current.key == key {
+			return current, true
+		}
+	}
+
+	return settingDefinition{}, false
+}
+
+func checkMinutes(value string) error {
+	minutes, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.New("must be a whole number of minutes")
+	}
+
+	if minutes < 0 {
+		return errors.New("can't be negative")
+	}
+
+	return nil
+}
+
+func checkPositive(value string) error {
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		return errors.New("must be a whole number above 0")
+	}
+
+	return nil
+}
+
+func checkBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return errors.New("must be true or false")
+	}
+
+	return nil
+}
+
+func checkPercent(value string) error {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return errors.New("must be a number of percent, 0 or more")
+	}
+
+	return nil
+}
+
+func checkNotEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("can't be empty")
+	}
+
+	return nil
+}
+
+func checkOneOf(values ...string) func(value string) error {
+	return func(value string) error {
+		for _, allowed := range values {
+			if value == allowed {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+	}
+}
+
+// setting returns the value of a setting, or its default when it isn't set
+func (r *runner) setting(name string) string {
+	value, ok := r.document.Configuration[name]
+	if ok {
+		return value
+	}
+
+	known, _ := definition(name)
+
+	return known.fallback
+}
+
+// checkSetting validates the value of a known setting, other settings can be anything
+func checkSetting(key string, value string) error {
+	known, ok := definition(key)
+	if !ok {
+		return nil
+	}
+
+	if err := known.check(value); err != nil {
+		return fmt.Errorf("%s %q is not valid: %s", key, value, err)
+	}
+
+	return nil
+}
+
+// SettingsList prints a table of every known setting with its value or default, and the other settings that are set.
+// With describe it explains each of them
+func (r *runner) SettingsList(describe bool) {
+	table := tablewriter.NewWriter(os.Stdout)
+
+	if describe {
+		table.SetHeader([]string{"Key", "Value", "Default", "Description"})
+		table.SetAutoWrapText(false)
+	} else {
+		table.SetHeader([]string{"Key", "Value"})
+	}
+
+	var row = func(key string, fallback string, description string) {
+		if describe {
+			table.Append([]string{key, r.document.Configuration[key], fallback, description})
+			return
+		}
+
+		table.Append([]string{key, r.setting(key)})
+	}
+
+	for _, current := range settingsSchema {
+		row(current.key, current.fallback, current.description)
+	}
+
+	var others = make([]string, 0)
+
+	for key := range r.document.Configuration {
+		if _, ok := definition(key); !ok {
+			others = append(others, key)
+		}
+	}
+
+	sort.Strings(others)
+
+	for _, key := range others {
+		row(key, "", "not used by timesheet")
+	}
+
+	table.Render()
+}
+
+// SettingsSet adds or updates a setting, known settings are validated first
+func (r *runner) SettingsSet(key string, value string) {
+	utils.ErrorHandler(checkSetting(key, value))
+
+	if _, ok := definition(key); !ok {
+		fmt.Fprintf(os.Stderr, "warning: %s is not a setting timesheet uses\n", key)
+	}
+
+	if r.document.Configuration == nil {
+		r.document.Configuration = make(map[string]string)
+	}
+
+	r.document.Configuration[key] = value
+}
+
+// SettingsUnset removes a setting, so its default is used again
+func (r *runner) SettingsUnset(key string) {
+	if _, ok := r.document.Configuration[key]; !ok {
+		utils.ErrorHandler(fmt.Errorf("%s is not set", key))
+	}
+
+	delete(r.document.Configuration, key)
+}
+
+// SettingsReset removes every setting, so the defaults are used
+func (r *runner) SettingsReset() {
+	r.document.Configuration = make(map[string]string)
+}
+
+// SetupOptions are settings given up front, and if setup can ask for the rest
+type SetupOptions struct {
+	Values         map[string]string
+	NonInteractive bool
+}
+
+// ask asks for a setting until the answer is valid, an empty answer keeps the current value.
+// When there is nothing more to read the current value is kept
+func (r *runner) ask(current settingDefinition, eof *bool) string {
+	var fallback = r.setting(current.key)
+
+	for {
+		var answer = ""
+
+		if !*eof {
+			fmt.Printf("%s, %s [%s]: ", current.key, current.description, fallback)
+
+			answer = r.reader.Execute(os.Stdin)
+			*eof = !strings.HasSuffix(answer, "\n")
+			answer = strings.TrimSpace(answer)
+
+			if *eof {
+				fmt.Println()
+			}
+		}
+
+		if answer == "" {
+			answer = fallback
+		}
+
+		var err = checkSetting(current.key, answer)
+		if err == nil {
+			return answer
+		}
+
+		if *eof {
+			utils.ErrorHandler(err)
+		}
+
+		fmt.Println(err)
+	}
+}
+
+// Setup asks for every setting with its current value as default, except those given as options.
+// When it isn't interactive only the given settings are set. Nothing is stored unless every value is valid
+func (r *runner) Setup(options SetupOptions) {
+	var values = make(map[string]string)
+
+	for key, value := range options.Values {
+		if _, ok := definition(key); !ok {
+			utils.ErrorHandler(fmt.Errorf("unknown setting %s", key))
+		}
+
+		utils.ErrorHandler(checkSetting(key, value))
+
+		values[key] = value
+	}
+
+	if !options.NonInteractive {
+		fmt.Println("Setup")
+		fmt.Println("This will replace your current settings but not your data, press enter to keep the value in brackets")
+
+		var eof = false
+
+		for _, current := range settingsSchema {
+			if _, ok := values[current.key]; !ok {
+				values[current.key] = r.ask(current, &eof)
+			}
+		}
+	}
+
+	if r.document.Configuration == nil {
+		r.document.Configuration = make(map[string]string)
+	}
+
+	for key, value := range values {
+		r.document.Configuration[key] = value
+	}
+}
@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"os"
+	"testing"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingDefaults(t *testing.T) {
+	d := models.Document{}
+
+	r := &runner{document: &d}
+
+	assert.Equal(t, "450", r.setting("workday"))
+	assert.Equal(t, "", r.setting("unknown"))
+
+	workday, breaktime := r.getSettings()
+	assert.Equal(t, 450, workday)
+	assert.Equal(t, 30, breaktime)
+
+	assert.Equal(t, 15, r.roundingRule().minutes)
+	assert.Panics(t, func() { r.settingToInt("unknown") })
+}
+
+func TestSettingsSetChecks(t *testing.T) {
+	d := models.Document{}
+
+	r := &runner{document: &d}
+
+	assert.Panics(t, func() { r.SettingsSet("workday", "abc") })
+	assert.Panics(t, func() { r.SettingsSet("break", "-5") })
+	assert.Panics(t, func() { r.SettingsSet("break_mode", "sometimes") })
+	assert.Panics(t, func() { r.SettingsSet("rounding_minutes", "0") })
+	assert.Panics(t, func() { r.SettingsSet("break_days_off", "maybe") })
+	assert.Panics(t, func() { r.SettingsSet("tax", "a lot") })
+	assert.Panics(t, func() { r.SettingsSet("schedule", "someday=450") })
+	assert.Panics(t, func() { r.SettingsSet("holidays", "christmas") })
+	assert.Panics(t, func() { r.SettingsSet("timezone", "Mars/Olympus") })
+	assert.Nil(t, d.Configuration)
+
+	r.SettingsSet("workday", "480")
+	r.SettingsSet("rounding", roundingUp)
+	r.SettingsSet("tax", "25.5")
+	r.SettingsSet("theme", "dark")
+	assert.Equal(t, map[string]string{"workday": "480", "rounding": "up", "tax": "25.5", "theme": "dark"}, d.Configuration)
+
+	r.SettingsUnset("workday")
+	assert.Equal(t, 450, r.settingToInt("workday"))
+	assert.Panics(t, func() { r.SettingsUnset("workday") })
+
+	r.SettingsList(true)
+
+	r.SettingsReset()
+	assert.Len(t, d.Configuration, 0)
+	assert.Equal(t, roundingNone, r.setting("rounding"))
+}
+
+func TestSettingsSchema(t *testing.T) {
+	for _, current := range settingsSchema {
+		assert.Nil(t, current.check(current.fallback), current.key)
+		assert.NotEqual(t, "", current.description, current.key)
+	}
+}
+
+type answers []string
+
+func (a *answers) Execute(f *os.File) string {
+	if len(*a) == 0 {
+		return ""
+	}
+
+	var line = (*a)[0]
+	*a = (*a)[1:]
+
+	return line
+}
+
+func TestSetup(t *testing.T) {
+	d := models.Document{Configuration: map[string]string{"break": "45"}}
+
+	r := &runner{document: &d, reader: &answers{"abc\n", "480\n", "mon=lots\n", "mon=480,fri=240\n", "2026-12-25\n", "Mars/Olympus\n", "Europe/Oslo\n", "\n", "sometimes\n", "gaps"}}
+
+	r.Setup(SetupOptions{Values: map[string]string{"tax": "25"}})
+
+	assert.Equal(t, "480", d.Configuration["workday"])
+	assert.Equal(t, "mon=480,fri=240", d.Configuration["schedule"])
+	assert.Equal(t, "2026-12-25", d.Configuration["holidays"])
+	assert.Equal(t, "Europe/Oslo", d.Configuration["timezone"])
+	assert.Equal(t, "45", d.Configuration["break"])
+	assert.Equal(t, breakModeGaps, d.Configuration["break_mode"])
+	assert.Equal(t, roundingNone, d.Configuration["rounding"])
+	assert.Equal(t, "25", d.Configuration["tax"])
+	assert.Len(t, d.Configuration, len(settingsSchema))
+
+	d = models.Document{}
+	r = &runner{document: &d, reader: &answers{}}
+
+	r.Setup(SetupOptions{Values: map[string]string{"workday": "400"}, NonInteractive: true})
+	assert.Equal(t, map[string]string{"workday": "400"}, d.Configuration)
+
+	assert.Panics(t, func() { r.Setup(SetupOptions{Values: map[string]string{"workday": "abc"}, NonInteractive: true}) })
+	assert.Panics(t, func() {
+		r.Setup(SetupOptions{Values: map[string]string{"timezone": "Mars/Olympus"}, NonInteractive: true})
+	})
+	assert.Equal(t, "400", d.Configuration["workday"])
+
+	d = models.Document{Configuration: map[string]string{"workday": "abc"}}
+	r = &runner{document: &d, reader: &answers{}}
+
+	assert.Panics(t, func() { r.Setup(SetupOptions{}) })
+}
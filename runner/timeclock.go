@@ -0,0 +1,177 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+// timeclockAccount is the account of events without a project, as timeclock entries need one
+const timeclockAccount = "work"
+
+var timeclockTimeFormats = []string{"2006/01/02 15:04:05", "2006/01/02 15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// writeTimeclock writes every event as a clock-in and clock-out, with the project as account and the note as description
+func (r *runner) writeTimeclock(w io.Writer) error {
+	for _, entry := range r.days() {
+		for _, item := range entry.item.Events {
+			s, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.Start)
+			if err != nil {
+				return err
+			}
+
+			e, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.End)
+			if err != nil {
+				return err
+			}
+
+			var account = item.Project
+			if account == "" {
+				account = timeclockAccount
+			}
+
+			var in = fmt.Sprintf("i %s %s", s.Format("2006/01/02 15:04:05"), account)
+			if item.Note != "" {
+				in += "  " + strings.Join(strings.Fields(item.Note), " ")
+			}
+
+			if _, err := fmt.Fprintf(w, "%s\no %s\n", in, e.Format("2006/01/02 15:04:05")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExportTimeclock writes the events to a timeclock file for ledger and hledger, or stdout without filename
+func (r *runner) ExportTimeclock(filename string) {
+	if filename == "" {
+		utils.ErrorHandler(r.writeTimeclock(os.Stdout))
+		return
+	}
+
+	f, err := os.Create(filename)
+	utils.ErrorHandler(err)
+
+	defer func() {
+		utils.ErrorHandler(f.Close())
+	}()
+
+	utils.ErrorHandler(r.writeTimeclock(f))
+}
+
+// parseTimeclockTime reads the date and time at the start of an entry and returns the rest of the line
+func parseTimeclockTime(value string) (time.Time, string, error) {
+	var fields = strings.SplitN(strings.TrimSpace(value), " ", 3)
+	if len(fields) < 2 {
+		return time.Time{}, "", fmt.Errorf("can't read time %s", value)
+	}
+
+	var rest = ""
+	if len(fields) == 3 {
+		rest = strings.TrimSpace(fields[2])
+	}
+
+	for _, format := range timeclockTimeFormats {
+		t, err := time.Parse(format, fields[0]+" "+fields[1])
+		if err == nil {
+			return t, rest, nil
+		}
+	}
+
+	return time.Time{}, "", fmt.Errorf("can't read time %s %s", fields[0], fields[1])
+}
+
+// readTimeclock pairs the clock-ins and clock-outs of a timeclock file as events, with the account as project
+func readTimeclock(f io.Reader) ([]importedEvent, error) {
+	var result = make([]importedEvent, 0)
+
+	var open *importedEvent
+
+	var scanner = bufio.NewScanner(f)
+
+	for number := 1; scanner.Scan(); number++ {
+		var line = strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.TrimSpace(line) == "" || strings.ContainsAny(line[:1], ";#*") {
+			continue
+		}
+
+		switch line[0] {
+		case 'i':
+			if open != nil {
+				open.status = fmt.Sprintf("error: clocked in again on line %d", number)
+				result = append(result, *open)
+			}
+
+			start, rest, err := parseTimeclockTime(line[1:])
+			if err != nil {
+				result = append(result, importedEvent{status: fmt.Sprintf("error: line %d: %s", number, err)})
+				open = nil
+
+				continue
+			}
+
+			var parts = strings.SplitN(rest, "  ", 2)
+
+			open = &importedEvent{start: start, event: models.EventItem{Project: strings.TrimSpace(parts[0])}}
+
+			if len(parts) == 2 {
+				open.event.Note = strings.TrimSpace(parts[1])
+			}
+		case 'o', 'O':
+			end, _, err := parseTimeclockTime(line[1:])
+
+			switch {
+			case err != nil:
+				result = append(result, importedEvent{status: fmt.Sprintf("error: line %d: %s", number, err)})
+			case open == nil:
+				result = append(result, importedEvent{end: end, status: fmt.Sprintf("error: clocked out without clocking in on line %d", number)})
+			default:
+				open.end = end
+
+				if end.Before(open.start) || end.Format("2006-01-02") != open.start.Format("2006-01-02") {
+					open.status = "error: events can't cross midnight"
+				}
+
+				result = append(result, *open)
+			}
+
+			open = nil
+		default:
+			result = append(result, importedEvent{status: fmt.Sprintf("error: line %d: unknown entry %s", number, line[:1])})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if open != nil {
+		open.status = "skipped: still clocked in"
+		result = append(result, *open)
+	}
+
+	return result, nil
+}
+
+// ImportTimeclock adds the events of a ledger or hledger timeclock file that aren't logged yet.
+// Nothing is added if any entry can't be read or when it is a dry run
+func (r *runner) ImportTimeclock(filename string, dryRun bool) {
+	f, err := os.Open(filename)
+	utils.ErrorHandler(err)
+
+	defer f.Close()
+
+	events, err := readTimeclock(f)
+	utils.ErrorHandler(err)
+
+	r.importEvents(events, dryRun)
+}
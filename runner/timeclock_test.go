@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTimeclock(t *testing.T) {
+	var content = "; comment\n" +
+		"i 2026/09/07 08:00:00 client:a  design review\n" +
+		"o 2026/09/07 10:00:00\n" +
+		"\n" +
+		"i 2026-09-08 09:00 b\n" +
+		"O 2026-09-08 09:45\n" +
+		"o 2026/09/08 10:00:00\n" +
+		"i 2026/09/09 22:00:00 b\n" +
+		"o 2026/09/10 01:00:00\n" +
+		"i 2026/09/11 08:00:00 b\n"
+
+	events, err := readTimeclock(strings.NewReader(content))
+	assert.Nil(t, err)
+	assert.Len(t, events, 5)
+
+	assert.Equal(t, time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), events[0].start)
+	assert.Equal(t, time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), events[0].end)
+	assert.Equal(t, models.EventItem{Project: "client:a", Note: "design review"}, events[0].event)
+	assert.Equal(t, "", events[0].status)
+
+	assert.Equal(t, models.EventItem{Project: "b"}, events[1].event)
+	assert.Equal(t, "", events[1].status)
+
+	assert.True(t, strings.HasPrefix(events[2].status, "error: clocked out"))
+	assert.Equal(t, "error: events can't cross midnight", events[3].status)
+	assert.Equal(t, "skipped: still clocked in", events[4].status)
+
+	events, err = readTimeclock(strings.NewReader("i yesterday\nx 2026/09/07 08:00:00\n"))
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.True(t, strings.HasPrefix(events[0].status, "error: line 1"))
+	assert.True(t, strings.HasPrefix(events[1].status, "error: line 2"))
+}
+
+func TestTimeclock(t *testing.T) {
+	d := models.Document{
+		Configuration: make(map[string]string),
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 10, 0, 0, 0, time.UTC), false, models.EventItem{Project: "a", Note: "design\nreview"})
+	r.Add(time.Date(2026, 9, 8, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 8, 9, 0, 0, 0, time.UTC), false, models.EventItem{})
+
+	var filename = "/tmp/timesheet.timeclock"
+
+	defer os.Remove(filename)
+
+	r.ExportTimeclock(filename)
+
+	content, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, "i 2026/09/07 08:00:00 a  design review\n"+
+		"o 2026/09/07 10:00:00\n"+
+		"i 2026/09/08 08:00:00 work\n"+
+		"o 2026/09/08 09:00:00\n", string(content))
+
+	d.Items = make(map[string]map[string]models.DayItem)
+
+	r.ImportTimeclock(filename, true)
+	assert.Len(t, d.Items, 0)
+
+	r.ImportTimeclock(filename, false)
+	assert.Equal(t, []models.EventItem{{Start: "08:00:00", End: "10:00:00", Project: "a", Note: "design review"}}, d.Items["2026"]["2026-09-07"].Events)
+	assert.Equal(t, []models.EventItem{{Start: "08:00:00", End: "09:00:00", Project: "work"}}, d.Items["2026"]["2026-09-08"].Events)
+
+	r.ImportTimeclock(filename, false)
+	assert.Len(t, d.Items["2026"]["2026-09-07"].Events, 1)
+
+	assert.Nil(t, ioutil.WriteFile(filename, []byte("o 2026/09/07 10:00:00\n"), 0600))
+	assert.Panics(t, func() { r.ImportTimeclock(filename, false) })
+	assert.Panics(t, func() { r.ImportTimeclock("/tmp/does-not-exist.timeclock", false) })
+}
@@ -0,0 +1,341 @@
+package runner
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/utils"
+)
+
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleDate
+	xlsxStyleTime
+	xlsxStyleHours
+	xlsxStyleHeader
+)
+
+// xlsxCell is a number, a text or a formula with the value it had when written
+type xlsxCell struct {
+	text    string
+	number  float64
+	formula string
+	style   int
+	empty   bool
+}
+
+type xlsxSheet struct {
+	name string
+	rows [][]xlsxCell
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+{{sheets}}</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="hh:mm"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="5">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+</cellXfs>
+<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>`
+
+// add adds a reference to another cell to a formula summing cells
+func (c *xlsxCell) add(reference string, value float64) {
+	if c.formula != "" {
+		c.formula += "+"
+	}
+
+	c.formula += reference
+	c.number += value
+}
+
+func xlsxText(text string, style int) xlsxCell {
+	return xlsxCell{text: text, style: style}
+}
+
+func xlsxNumber(number float64, style int) xlsxCell {
+	return xlsxCell{number: number, style: style}
+}
+
+func xlsxFormula(formula string, value float64, style int) xlsxCell {
+	return xlsxCell{formula: formula, number: value, style: style}
+}
+
+// xlsxDate is the serial number spreadsheets use for a date, days since 1899-12-30
+func xlsxDate(t time.Time) float64 {
+	return t.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+}
+
+func xlsxReference(column int, row int) string {
+	return fmt.Sprintf("%c%d", 'A'+column, row)
+}
+
+func escapeXML(value string) string {
+	var buffer bytes.Buffer
+
+	_ = xml.EscapeText(&buffer, []byte(value))
+
+	return buffer.String()
+}
+
+func writeXLSXSheet(w io.Writer, sheet xlsxSheet) error {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	buffer.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	for i, row := range sheet.rows {
+		fmt.Fprintf(&buffer, `<row r="%d">`, i+1)
+
+		for j, cell := range row {
+			var reference = xlsxReference(j, i+1)
+
+			switch {
+			case cell.empty:
+				fmt.Fprintf(&buffer, `<c r="%s" s="%d"/>`, reference, cell.style)
+			case cell.formula != "":
+				fmt.Fprintf(&buffer, `<c r="%s" s="%d"><f>%s</f><v>%s</v></c>`, reference, cell.style, escapeXML(cell.formula), strconv.FormatFloat(cell.number, 'f', -1, 64))
+			case cell.text != "":
+				fmt.Fprintf(&buffer, `<c r="%s" s="%d" t="inlineStr"><is><t>%s</t></is></c>`, reference, cell.style, escapeXML(cell.text))
+			default:
+				fmt.Fprintf(&buffer, `<c r="%s" s="%d"><v>%s</v></c>`, reference, cell.style, strconv.FormatFloat(cell.number, 'f', -1, 64))
+			}
+		}
+
+		buffer.WriteString(`</row>`)
+	}
+
+	buffer.WriteString(`</sheetData></worksheet>`)
+
+	_, err := w.Write(buffer.Bytes())
+
+	return err
+}
+
+// writeXLSX writes the sheets as a workbook that recomputes its formulas when opened
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	var archive = zip.NewWriter(w)
+
+	var overrides, entries, relationships bytes.Buffer
+
+	for i, sheet := range sheets {
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", i+1)
+		fmt.Fprintf(&entries, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(sheet.name), i+1, i+1)
+		fmt.Fprintf(&relationships, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`+"\n", i+1, i+1)
+	}
+
+	fmt.Fprintf(&relationships, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`+"\n", len(sheets)+1)
+
+	var files = []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", string(bytes.Replace([]byte(xlsxContentTypes), []byte("{{sheets}}"), overrides.Bytes(), 1))},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>` + entries.String() + `</sheets>
+<calcPr fullCalcOnLoad="1"/>
+</workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+` + relationships.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(f, file.content); err != nil {
+			return err
+		}
+	}
+
+	for i, sheet := range sheets {
+		f, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+
+		if err := writeXLSXSheet(f, sheet); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// xlsxSheets lays the days out as a sheet per month with start, end, break, total and expected hours per day,
+// and a summary sheet like SummaryYear summing the totals of the months with formulas
+func (r *runner) xlsxSheets() []xlsxSheet {
+	var expectation = r.expectation()
+
+	var rule = r.breakRule()
+
+	var header = []xlsxCell{
+		xlsxText("Date", xlsxStyleHeader),
+		xlsxText("Start", xlsxStyleHeader),
+		xlsxText("End", xlsxStyleHeader),
+		xlsxText("Break", xlsxStyleHeader),
+		xlsxText("Total", xlsxStyleHeader),
+		xlsxText("Expected", xlsxStyleHeader),
+	}
+
+	var months = make([]*xlsxSheet, 0)
+
+	var totals = make(map[string][2]float64)
+
+	var sheet *xlsxSheet
+
+	for _, entry := range r.days() {
+		if sheet == nil || sheet.name != entry.day[:7] {
+			if sheet != nil {
+				months = append(months, sheet)
+			}
+
+			sheet = &xlsxSheet{name: entry.day[:7], rows: [][]xlsxCell{header}}
+		}
+
+		date, err := utils.TimeFromDateString(entry.day)
+		utils.ErrorHandler(err)
+
+		var minutes, deduction = r.workedMinutes(rule, entry)
+
+		var total = float64(minutes-deduction) / 60
+
+		var start, end = xlsxCell{empty: true, style: xlsxStyleTime}, xlsxCell{empty: true, style: xlsxStyleTime}
+
+		var span = 0.0
+
+		if len(entry.item.Events) > 0 {
+			s, err := utils.TimeFromDateStringAndTimeString2(entry.day, entry.item.Events[0].Start)
+			utils.ErrorHandler(err)
+
+			var e = s
+
+			for _, item := range entry.item.Events {
+				current, err := utils.TimeFromDateStringAndTimeString2(entry.day, item.End)
+				utils.ErrorHandler(err)
+
+				if current.After(e) {
+					e = current
+				}
+			}
+
+			start = xlsxNumber(s.Sub(date).Hours()/24, xlsxStyleTime)
+			end = xlsxNumber(e.Sub(date).Hours()/24, xlsxStyleTime)
+			span = e.Sub(s).Hours()
+		}
+
+		var expected = float64(expectation.minutes(entry)) / 60
+
+		var row = len(sheet.rows) + 1
+
+		sheet.rows = append(sheet.rows, []xlsxCell{
+			xlsxNumber(xlsxDate(date), xlsxStyleDate),
+			start,
+			end,
+			xlsxNumber(span-total, xlsxStyleHours),
+			xlsxFormula(fmt.Sprintf("(C%d-B%d)*24-D%d", row, row, row), total, xlsxStyleHours),
+			xlsxNumber(expected, xlsxStyleHours),
+		})
+
+		var sums = totals[sheet.name]
+		sums[0] += total
+		sums[1] += expected
+		totals[sheet.name] = sums
+	}
+
+	if sheet != nil {
+		months = append(months, sheet)
+	}
+
+	var summary = xlsxSheet{
+		name: "Summary",
+		rows: [][]xlsxCell{{
+			xlsxText("Year", xlsxStyleHeader),
+			xlsxText("Expected", xlsxStyleHeader),
+			xlsxText("Total", xlsxStyleHeader),
+			xlsxText("Difference", xlsxStyleHeader),
+		}},
+	}
+
+	var result = []xlsxSheet{summary}
+
+	for _, month := range months {
+		var last = len(month.rows)
+
+		month.rows = append(month.rows, []xlsxCell{
+			xlsxText("Total", xlsxStyleHeader),
+			{empty: true},
+			{empty: true},
+			{empty: true},
+			xlsxFormula(fmt.Sprintf("SUM(E2:E%d)", last), totals[month.name][0], xlsxStyleHours),
+			xlsxFormula(fmt.Sprintf("SUM(F2:F%d)", last), totals[month.name][1], xlsxStyleHours),
+		})
+
+		var year = month.name[:4]
+
+		if result[0].rows[len(result[0].rows)-1][0].text != year {
+			var row = len(result[0].rows) + 1
+
+			result[0].rows = append(result[0].rows, []xlsxCell{
+				xlsxText(year, xlsxStyleDefault),
+				xlsxFormula("", 0, xlsxStyleHours),
+				xlsxFormula("", 0, xlsxStyleHours),
+				xlsxFormula(fmt.Sprintf("C%d-B%d", row, row), 0, xlsxStyleHours),
+			})
+		}
+
+		var current = result[0].rows[len(result[0].rows)-1]
+
+		current[1].add(fmt.Sprintf("'%s'!F%d", month.name, last+1), totals[month.name][1])
+		current[2].add(fmt.Sprintf("'%s'!E%d", month.name, last+1), totals[month.name][0])
+		current[3].number = current[2].number - current[1].number
+
+		result = append(result, *month)
+	}
+
+	return result
+}
+
+// ExportXLSX writes a workbook with a sheet per month and a summary per year
+func (r *runner) ExportXLSX(filename string) {
+	f, err := os.Create(filename)
+	utils.ErrorHandler(err)
+
+	defer func() {
+		utils.ErrorHandler(f.Close())
+	}()
+
+	utils.ErrorHandler(writeXLSX(f, r.xlsxSheets()))
+}
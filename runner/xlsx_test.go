@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"git.sr.ht/~hjertnes/timesheet/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXLSXSheets(t *testing.T) {
+	d := models.Document{
+		Configuration: map[string]string{"workday": "450", "break": "30"},
+		Items:         make(map[string]map[string]models.DayItem),
+	}
+
+	r := &runner{
+		document: &d,
+	}
+
+	r.Add(time.Date(2026, 9, 7, 12, 30, 0, 0, time.UTC), time.Date(2026, 9, 7, 16, 30, 0, 0, time.UTC), false, models.EventItem{})
+	r.Add(time.Date(2026, 9, 7, 8, 0, 0, 0, time.UTC), time.Date(2026, 9, 7, 12, 0, 0, 0, time.UTC), false, models.EventItem{})
+	r.Add(time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC), time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC), false, models.EventItem{})
+	r.Off(time.Date(2027, 1, 4, 0, 0, 0, 0, time.UTC))
+
+	var sheets = r.xlsxSheets()
+
+	var names = make([]string, 0)
+	for _, sheet := range sheets {
+		names = append(names, sheet.name)
+	}
+
+	assert.Equal(t, []string{"Summary", "2026-09", "2026-10", "2027-01"}, names)
+
+	var day = sheets[1].rows[1]
+	assert.Equal(t, xlsxDate(time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC)), day[0].number)
+	assert.Equal(t, 8.0/24, day[1].number)
+	assert.Equal(t, 16.5/24, day[2].number)
+	assert.Equal(t, 1.0, day[3].number)
+	assert.Equal(t, "(C2-B2)*24-D2", day[4].formula)
+	assert.Equal(t, 7.5, day[4].number)
+	assert.Equal(t, 7.5, day[5].number)
+
+	assert.Equal(t, "SUM(E2:E2)", sheets[1].rows[2][4].formula)
+
+	var off = sheets[3].rows[1]
+	assert.True(t, off[1].empty)
+	assert.Equal(t, 0.5, off[3].number)
+	assert.Equal(t, -0.5, off[4].number)
+
+	var summary = sheets[0].rows
+	assert.Len(t, summary, 3)
+	assert.Equal(t, "2026", summary[1][0].text)
+	assert.Equal(t, "'2026-09'!F3+'2026-10'!F3", summary[1][1].formula)
+	assert.Equal(t, 15.0, summary[1][1].number)
+	assert.Equal(t, "'2026-09'!E3+'2026-10'!E3", summary[1][2].formula)
+	assert.Equal(t, 8.0, summary[1][2].number)
+	assert.Equal(t, "C2-B2", summary[1][3].formula)
+	assert.Equal(t, -7.0, summary[1][3].number)
+	assert.Equal(t, -8.0, summary[2][3].number)
+
+	var filename = "/tmp/timesheet.xlsx"
+
+	defer os.Remove(filename)
+
+	r.ExportXLSX(filename)
+
+	archive, err := zip.OpenReader(filename)
+	assert.Nil(t, err)
+
+	defer archive.Close()
+
+	var files = make([]string, 0)
+
+	for _, f := range archive.File {
+		files = append(files, f.Name)
+
+		content, err := f.Open()
+		assert.Nil(t, err)
+
+		data, err := ioutil.ReadAll(content)
+		assert.Nil(t, err)
+
+		var decoder = xml.NewDecoder(bytes.NewReader(data))
+
+		for {
+			_, err = decoder.Token()
+			if err != nil {
+				break
+			}
+		}
+
+		assert.Equal(t, io.EOF, err, f.Name)
+	}
+
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "xl/workbook.xml")
+	assert.Contains(t, files, "xl/worksheets/sheet4.xml")
+}